@@ -3,6 +3,8 @@ package batch
 import (
 	"context"
 	"time"
+
+	"github.com/emptyOVO/mrkit-go/master"
 )
 
 // BenchmarkConfig configures benchmark workflow.
@@ -20,6 +22,7 @@ type BenchmarkResult struct {
 	PipelineDuration time.Duration
 	ValidateDuration time.Duration
 	TotalDuration    time.Duration
+	Counters         master.Counters
 }
 
 func RunBenchmark(ctx context.Context, cfg BenchmarkConfig) (BenchmarkResult, error) {
@@ -42,7 +45,7 @@ func RunBenchmark(ctx context.Context, cfg BenchmarkConfig) (BenchmarkResult, er
 
 	s := time.Now()
 	cfg.Pipeline.DB = cfg.DB
-	if err := RunPipeline(ctx, cfg.Pipeline); err != nil {
+	if result.Counters, err = RunPipelineWithCounters(ctx, cfg.Pipeline); err != nil {
 		return result, err
 	}
 	result.PipelineDuration = time.Since(s)
@@ -2,6 +2,7 @@ package batch
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/emptyOVO/mrkit-go/batch/mysql_batch"
 	"github.com/emptyOVO/mrkit-go/batch/redis_batch"
+	"github.com/emptyOVO/mrkit-go/master"
+	"github.com/emptyOVO/mrkit-go/worker"
 )
 
 var transformEnvMu sync.Mutex
@@ -19,6 +22,8 @@ type FlowConfig struct {
 	Source    FlowSourceConfig    `json:"source"`
 	Transform FlowTransformConfig `json:"transform"`
 	Sink      FlowSinkConfig      `json:"sink"`
+	// Outputs bind named outputs of the transform to sinks of their own.
+	Outputs []FlowOutputConfig `json:"outputs"`
 }
 
 type FlowSourceConfig struct {
@@ -38,6 +43,7 @@ type FlowTransformConfig struct {
 	InRAM      bool              `json:"in_ram"`
 	Port       int               `json:"port"`
 	Params     map[string]string `json:"params"`
+	CacheFiles []string          `json:"cache_files"`
 }
 
 type FlowSinkConfig struct {
@@ -48,6 +54,15 @@ type FlowSinkConfig struct {
 	RedisConfig RedisSinkConfig `json:"redis_config"`
 }
 
+// FlowOutputConfig sends the named output Name, written by the transform with
+// MrContext.EmitTo, to Sink. The sink reads mr-<name>-*.txt. An output only
+// gets files from the tasks that emitted to it, so a run without any row for
+// it imports nothing rather than failing.
+type FlowOutputConfig struct {
+	Name string         `json:"name"`
+	Sink FlowSinkConfig `json:"sink"`
+}
+
 func (c *FlowConfig) withDefaults() {
 	if c.Source.Type == "" {
 		c.Source.Type = "mysql"
@@ -71,6 +86,17 @@ func (c *FlowConfig) withDefaults() {
 	c.Sink.Config.WithDefaults()
 	c.Source.RedisConfig.WithDefaults()
 	c.Sink.RedisConfig.WithDefaults()
+	for i := range c.Outputs {
+		out := &c.Outputs[i]
+		if out.Sink.Type == "" {
+			out.Sink.Type = "mysql"
+		}
+		glob := worker.NamedOutputGlob("", out.Name)
+		out.Sink.Config.InputGlob, out.Sink.RedisConfig.InputGlob = glob, glob
+		out.Sink.Config.AllowEmpty, out.Sink.RedisConfig.AllowEmpty = true, true
+		out.Sink.Config.WithDefaults()
+		out.Sink.RedisConfig.WithDefaults()
+	}
 }
 
 // FlowBenchmarkResult captures source/transform/sink stage durations.
@@ -79,6 +105,7 @@ type FlowBenchmarkResult struct {
 	TransformDuration time.Duration
 	SinkDuration      time.Duration
 	TotalDuration     time.Duration
+	Counters          master.Counters
 }
 
 // RunFlow executes source -> transform -> sink defined by FlowConfig.
@@ -87,6 +114,13 @@ func RunFlow(ctx context.Context, cfg FlowConfig) error {
 	return err
 }
 
+// RunFlowWithCounters executes the flow like RunFlow and returns the user
+// counter totals of the transform.
+func RunFlowWithCounters(ctx context.Context, cfg FlowConfig) (master.Counters, error) {
+	result, err := runFlowInternal(ctx, cfg, false)
+	return result.Counters, err
+}
+
 // RunFlowBenchmark executes a config-driven flow and reports stage durations.
 func RunFlowBenchmark(ctx context.Context, cfg FlowConfig) (FlowBenchmarkResult, error) {
 	return runFlowInternal(ctx, cfg, true)
@@ -135,19 +169,17 @@ func runFlowInternal(ctx context.Context, cfg FlowConfig, collectDur bool) (Flow
 			return nil
 		}
 
-		var inputGlob string
-		switch cfg.Sink.Type {
-		case "mysql":
-			inputGlob = mysql_batch.NewSinkAdapter(cfg.Sink.Config).InputGlob()
-		case "redis":
-			inputGlob = redis_batch.NewSinkAdapter(cfg.Sink.Redis, cfg.Sink.RedisConfig).InputGlob()
-		default:
+		inputGlob := sinkInputGlob(cfg.Sink)
+		if inputGlob == "" {
 			return nil
 		}
 		cleanupReduceOutputs(inputGlob)
+		for _, out := range cfg.Outputs {
+			cleanupReduceOutputs(sinkInputGlob(out.Sink))
+		}
 
 		sTransform := time.Now()
-		if err := runMapReduce(ctx, files, pluginPath, cfg.Transform); err != nil {
+		if bench.Counters, err = runMapReduce(ctx, files, pluginPath, cfg.Transform); err != nil {
 			return err
 		}
 		if collectDur {
@@ -155,23 +187,13 @@ func runFlowInternal(ctx context.Context, cfg FlowConfig, collectDur bool) (Flow
 		}
 
 		sSink := time.Now()
-		switch cfg.Sink.Type {
-		case "mysql":
-			sinkDB, err := openDB(ctx, cfg.Sink.DB)
-			if err != nil {
-				return err
-			}
-			err = mysql_batch.NewSinkAdapter(cfg.Sink.Config).Import(ctx, sinkDB)
-			sinkDB.Close()
-			if err != nil {
-				return err
-			}
-		case "redis":
-			if err := redis_batch.NewSinkAdapter(cfg.Sink.Redis, cfg.Sink.RedisConfig).Import(ctx); err != nil {
-				return err
+		if err := importSink(ctx, cfg.Sink); err != nil {
+			return err
+		}
+		for _, out := range cfg.Outputs {
+			if err := importSink(ctx, out.Sink); err != nil {
+				return fmt.Errorf("output %v: %w", out.Name, err)
 			}
-		default:
-			return nil
 		}
 		if collectDur {
 			bench.SinkDuration = time.Since(sSink)
@@ -187,6 +209,34 @@ func runFlowInternal(ctx context.Context, cfg FlowConfig, collectDur bool) (Flow
 	return bench, nil
 }
 
+// sinkInputGlob returns the reduce outputs a sink imports, "" for an unknown
+// sink type.
+func sinkInputGlob(sink FlowSinkConfig) string {
+	switch sink.Type {
+	case "mysql":
+		return mysql_batch.NewSinkAdapter(sink.Config).InputGlob()
+	case "redis":
+		return redis_batch.NewSinkAdapter(sink.Redis, sink.RedisConfig).InputGlob()
+	}
+	return ""
+}
+
+// importSink loads the reduce outputs of a sink into it.
+func importSink(ctx context.Context, sink FlowSinkConfig) error {
+	switch sink.Type {
+	case "mysql":
+		sinkDB, err := openDB(ctx, sink.DB)
+		if err != nil {
+			return err
+		}
+		defer sinkDB.Close()
+		return mysql_batch.NewSinkAdapter(sink.Config).Import(ctx, sinkDB)
+	case "redis":
+		return redis_batch.NewSinkAdapter(sink.Redis, sink.RedisConfig).Import(ctx)
+	}
+	return nil
+}
+
 func withTransformParams(params map[string]string, run func() error) error {
 	if len(params) == 0 {
 		return run()
@@ -226,13 +276,15 @@ func cleanupReduceOutputs(inputGlob string) {
 	}
 }
 
-func runMapReduce(ctx context.Context, files []string, pluginPath string, tf FlowTransformConfig) error {
-	return RunMapReduce(ctx, MapReduceRunConfig{
+func runMapReduce(ctx context.Context, files []string, pluginPath string, tf FlowTransformConfig) (master.Counters, error) {
+	return RunMapReduceWithCounters(ctx, MapReduceRunConfig{
 		Files:      files,
 		PluginPath: pluginPath,
 		Reducers:   tf.Reducers,
 		Workers:    tf.Workers,
 		InRAM:      tf.InRAM,
 		Port:       tf.Port,
+		Params:     tf.Params,
+		CacheFiles: tf.CacheFiles,
 	})
 }
@@ -0,0 +1,147 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis serves the few commands the redis sink sends, over a map of
+// hashes reduced to their single value.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (r *fakeRedis) serve(t *testing.T) int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go r.handle(conn)
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func (r *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		switch args[0] {
+		case "SCAN":
+			prefix := strings.TrimSuffix(args[3], "*")
+			var keys []string
+			for k := range r.data {
+				if strings.HasPrefix(k, prefix) {
+					keys = append(keys, k)
+				}
+			}
+			fmt.Fprintf(conn, "*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+			for _, k := range keys {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(k), k)
+			}
+		case "DEL":
+			delete(r.data, args[1])
+			fmt.Fprint(conn, ":1\r\n")
+		case "HSET":
+			r.data[args[1]] = args[3]
+			fmt.Fprint(conn, ":1\r\n")
+		default:
+			fmt.Fprint(conn, "+PONG\r\n")
+		}
+		r.mu.Unlock()
+	}
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestFlowOutputWithoutRows(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	redis := &fakeRedis{data: map[string]string{"err:stale": "1"}}
+	conn := RedisConnConfig{Port: redis.serve(t)}
+	sink := func(prefix string) FlowSinkConfig {
+		return FlowSinkConfig{Type: "redis", Redis: conn, RedisConfig: RedisSinkConfig{KeyPrefix: prefix, Replace: true}}
+	}
+	cfg := FlowConfig{
+		Version:   FlowVersionV1,
+		Source:    FlowSourceConfig{Type: "redis", RedisConfig: RedisSourceConfig{KeyPattern: "src:*"}},
+		Transform: FlowTransformConfig{Type: "builtin", Builtin: "count"},
+		Sink:      sink("out:"),
+		Outputs:   []FlowOutputConfig{{Name: "daily", Sink: sink("day:")}, {Name: "errors", Sink: sink("err:")}},
+	}
+	cfg.withDefaults()
+	if err := ValidateFlowConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	// The transform emitted to "daily" only, and nothing to the main output.
+	if err := os.WriteFile("mr-daily-r-0.txt", []byte("mon 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := importSink(context.Background(), cfg.Sink); err == nil {
+		t.Error("the main sink should still require reduce outputs")
+	}
+	for _, out := range cfg.Outputs {
+		if err := importSink(context.Background(), out.Sink); err != nil {
+			t.Fatalf("output %v: %v", out.Name, err)
+		}
+	}
+	redis.mu.Lock()
+	defer redis.mu.Unlock()
+	if len(redis.data) != 1 || redis.data["day:mon"] != "3" {
+		t.Errorf("expected day:mon only, stale rejects replaced by none, got %v", redis.data)
+	}
+}
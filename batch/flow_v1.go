@@ -30,9 +30,6 @@ func ValidateFlowConfig(cfg FlowConfig) error {
 	if cfg.Source.Type != "mysql" && cfg.Source.Type != "redis" {
 		return fmt.Errorf("unsupported source.type: %s", cfg.Source.Type)
 	}
-	if cfg.Sink.Type != "mysql" && cfg.Sink.Type != "redis" {
-		return fmt.Errorf("unsupported sink.type: %s", cfg.Sink.Type)
-	}
 	if cfg.Transform.Type != "mapreduce" && cfg.Transform.Type != "builtin" {
 		return fmt.Errorf("unsupported transform.type: %s", cfg.Transform.Type)
 	}
@@ -50,17 +47,20 @@ func ValidateFlowConfig(cfg FlowConfig) error {
 			return fmt.Errorf("source.redis_config.key_pattern is required for redis source")
 		}
 	}
-	switch cfg.Sink.Type {
-	case "mysql":
-		if cfg.Sink.DB.User == "" || cfg.Sink.DB.Database == "" {
-			return fmt.Errorf("sink.db.user and sink.db.database are required for mysql sink")
+	if err := validateSink("sink", cfg.Sink); err != nil {
+		return err
+	}
+	names := make(map[string]bool)
+	for i, out := range cfg.Outputs {
+		if out.Name == "out" || !identifierRe.MatchString(out.Name) {
+			return fmt.Errorf("invalid outputs[%d].name: %q", i, out.Name)
 		}
-		if strings.TrimSpace(cfg.Sink.Config.TargetTable) == "" {
-			return fmt.Errorf("sink.config.targettable is required for mysql sink")
+		if names[out.Name] {
+			return fmt.Errorf("output %q is bound twice", out.Name)
 		}
-	case "redis":
-		if strings.TrimSpace(cfg.Sink.RedisConfig.KeyPrefix) == "" {
-			return fmt.Errorf("sink.redis_config.key_prefix is required for redis sink")
+		names[out.Name] = true
+		if err := validateSink(fmt.Sprintf("outputs[%d].sink", i), out.Sink); err != nil {
+			return err
 		}
 	}
 
@@ -97,6 +97,26 @@ func ValidateFlowConfig(cfg FlowConfig) error {
 	return nil
 }
 
+// validateSink checks the sink config found at field.
+func validateSink(field string, sink FlowSinkConfig) error {
+	switch sink.Type {
+	case "mysql":
+		if sink.DB.User == "" || sink.DB.Database == "" {
+			return fmt.Errorf("%s.db.user and %s.db.database are required for mysql sink", field, field)
+		}
+		if strings.TrimSpace(sink.Config.TargetTable) == "" {
+			return fmt.Errorf("%s.config.targettable is required for mysql sink", field)
+		}
+	case "redis":
+		if strings.TrimSpace(sink.RedisConfig.KeyPrefix) == "" {
+			return fmt.Errorf("%s.redis_config.key_prefix is required for redis sink", field)
+		}
+	default:
+		return fmt.Errorf("unsupported %s.type: %s", field, sink.Type)
+	}
+	return nil
+}
+
 func resolveTransformPlugin(cfg FlowTransformConfig) (string, error) {
 	if cfg.Type == "mapreduce" {
 		return cfg.PluginPath, nil
@@ -55,6 +55,9 @@ type SinkConfig struct {
 	InputGlob   string `json:"inputglob"`
 	Replace     bool   `json:"replace"`
 	BatchSize   int    `json:"batchsize"`
+	// AllowEmpty imports nothing, instead of failing, when InputGlob matches
+	// no file. Replace still empties the target table.
+	AllowEmpty bool `json:"allow_empty"`
 }
 
 func (c *SinkConfig) WithDefaults() {
@@ -35,7 +35,7 @@ func ImportReduceOutputs(ctx context.Context, db *sql.DB, cfg SinkConfig) error
 	if err != nil {
 		return err
 	}
-	if len(files) == 0 {
+	if len(files) == 0 && !cfg.AllowEmpty {
 		return fmt.Errorf("no reduce output files matched: %s", cfg.InputGlob)
 	}
 
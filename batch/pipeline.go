@@ -5,19 +5,28 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/emptyOVO/mrkit-go/master"
 )
 
 // RunPipeline executes MySQL source -> MapReduce -> MySQL sink in-process.
 func RunPipeline(ctx context.Context, cfg PipelineConfig) error {
+	_, err := RunPipelineWithCounters(ctx, cfg)
+	return err
+}
+
+// RunPipelineWithCounters executes the pipeline like RunPipeline and returns
+// the user counter totals of the job.
+func RunPipelineWithCounters(ctx context.Context, cfg PipelineConfig) (master.Counters, error) {
 	cfg.withDefaults()
 	if cfg.PluginPath == "" {
-		return fmt.Errorf("plugin path is required")
+		return nil, fmt.Errorf("plugin path is required")
 	}
 	if cfg.Source.Table == "" {
-		return fmt.Errorf("source table is required")
+		return nil, fmt.Errorf("source table is required")
 	}
 	if cfg.Sink.TargetTable == "" {
-		return fmt.Errorf("target table is required")
+		return nil, fmt.Errorf("target table is required")
 	}
 
 	sourceDBCfg := cfg.SourceDB
@@ -31,22 +40,22 @@ func RunPipeline(ctx context.Context, cfg PipelineConfig) error {
 
 	sourceDB, err := openDB(ctx, sourceDBCfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer sourceDB.Close()
 
 	sinkDB, err := openDB(ctx, sinkDBCfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer sinkDB.Close()
 
 	files, err := ExportSourceByPKRange(ctx, sourceDB, cfg.Source)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(files) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// cleanup old reduce outputs before a new run.
@@ -56,16 +65,17 @@ func RunPipeline(ctx context.Context, cfg PipelineConfig) error {
 		}
 	}
 
-	if err := RunMapReduce(ctx, MapReduceRunConfig{
+	counters, err := RunMapReduceWithCounters(ctx, MapReduceRunConfig{
 		Files:      files,
 		PluginPath: cfg.PluginPath,
 		Reducers:   cfg.Reducers,
 		Workers:    cfg.Workers,
 		InRAM:      cfg.InRAM,
 		Port:       cfg.Port,
-	}); err != nil {
-		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return ImportReduceOutputs(ctx, sinkDB, cfg.Sink)
+	return counters, ImportReduceOutputs(ctx, sinkDB, cfg.Sink)
 }
@@ -14,6 +14,9 @@ type SinkConfig struct {
 	ValueField string `json:"value_field"`
 	InputGlob  string `json:"inputglob"`
 	Replace    bool   `json:"replace"`
+	// AllowEmpty imports nothing, instead of failing, when InputGlob matches
+	// no file. Replace still deletes the keys under KeyPrefix.
+	AllowEmpty bool `json:"allow_empty"`
 }
 
 func (c *SinkConfig) WithDefaults() {
@@ -41,7 +44,7 @@ func ImportReduceOutputs(ctx context.Context, connCfg ConnConfig, cfg SinkConfig
 	if err != nil {
 		return err
 	}
-	if len(files) == 0 {
+	if len(files) == 0 && !cfg.AllowEmpty {
 		return fmt.Errorf("no reduce output files matched: %s", cfg.InputGlob)
 	}
 
@@ -3,6 +3,8 @@ package batch
 import (
 	"context"
 	"sync"
+
+	"github.com/emptyOVO/mrkit-go/master"
 )
 
 // MapReduceRunConfig describes a runtime invocation for a map-reduce job.
@@ -13,6 +15,10 @@ type MapReduceRunConfig struct {
 	Workers    int
 	InRAM      bool
 	Port       int
+	// Params and CacheFiles are the side inputs of the job, see
+	// master.SideInputs.
+	Params     map[string]string
+	CacheFiles []string
 }
 
 // Runner abstracts runtime startup strategy for map-reduce execution.
@@ -20,6 +26,13 @@ type Runner interface {
 	Run(ctx context.Context, cfg MapReduceRunConfig) error
 }
 
+// CountersRunner is a Runner that can also return the user counter totals of
+// the job.
+type CountersRunner interface {
+	Runner
+	RunWithCounters(ctx context.Context, cfg MapReduceRunConfig) (master.Counters, error)
+}
+
 var (
 	defaultRunner   Runner = LegacyRunner{}
 	defaultRunnerMu sync.RWMutex
@@ -46,3 +59,14 @@ func DefaultRunner() Runner {
 func RunMapReduce(ctx context.Context, cfg MapReduceRunConfig) error {
 	return DefaultRunner().Run(ctx, cfg)
 }
+
+// RunMapReduceWithCounters executes map-reduce like RunMapReduce and returns
+// the user counter totals of the job, or nil counters if the configured
+// runner is not a CountersRunner.
+func RunMapReduceWithCounters(ctx context.Context, cfg MapReduceRunConfig) (master.Counters, error) {
+	r := DefaultRunner()
+	if cr, ok := r.(CountersRunner); ok {
+		return cr.RunWithCounters(ctx, cfg)
+	}
+	return nil, r.Run(ctx, cfg)
+}
@@ -0,0 +1,64 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	mapreduce "github.com/emptyOVO/mrkit-go"
+	"github.com/emptyOVO/mrkit-go/master"
+)
+
+// ClusterRunner submits jobs to a long-lived master started with
+// mapreduce.ServeMaster, so that every flow shares its worker pool instead of
+// starting workers of its own. Outputs land in the current directory, which
+// the workers must be able to write.
+type ClusterRunner struct {
+	MasterAddr string
+}
+
+func (r ClusterRunner) Run(ctx context.Context, cfg MapReduceRunConfig) error {
+	_, err := r.RunWithCounters(ctx, cfg)
+	return err
+}
+
+// RunWithCounters runs the job like Run and returns its user counter totals,
+// read from the job status once the job is done.
+func (r ClusterRunner) RunWithCounters(ctx context.Context, cfg MapReduceRunConfig) (master.Counters, error) {
+	if len(cfg.Files) == 0 {
+		return nil, nil
+	}
+	if r.MasterAddr == "" {
+		return nil, fmt.Errorf("master address is required")
+	}
+	if cfg.PluginPath == "" {
+		return nil, fmt.Errorf("plugin path is required")
+	}
+	if cfg.Reducers <= 0 {
+		return nil, fmt.Errorf("reducers must be > 0")
+	}
+	outDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	// Transform params are set in this process, so forward the ones the
+	// remote master needs through the spec.
+	id, err := mapreduce.RunJob(ctx, r.MasterAddr, master.JobSpec{
+		Files:       cfg.Files,
+		Plugin:      cfg.PluginPath,
+		NReduce:     cfg.Reducers,
+		OutputDir:   outDir,
+		Compression: os.Getenv("MR_COMPRESSION"),
+		Partitioner: os.Getenv("MR_PARTITIONER"),
+		InputFormat: os.Getenv("MR_INPUT_FORMAT"),
+		SideInputs:  master.SideInputs{Params: cfg.Params, CacheFiles: cfg.CacheFiles},
+	})
+	if err != nil {
+		return nil, err
+	}
+	st, err := mapreduce.JobStatus(ctx, r.MasterAddr, id)
+	if err != nil {
+		return nil, err
+	}
+	return master.CountersFromRPC(st.Counters), nil
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	mapreduce "github.com/emptyOVO/mrkit-go"
+	"github.com/emptyOVO/mrkit-go/master"
 )
 
 // LegacyRunner uses the current in-process legacy mapreduce runtime.
@@ -14,46 +15,58 @@ type LegacyRunner struct{}
 
 var legacyRuntimeMu sync.Mutex
 
-func (LegacyRunner) Run(ctx context.Context, cfg MapReduceRunConfig) error {
+func (r LegacyRunner) Run(ctx context.Context, cfg MapReduceRunConfig) error {
+	_, err := r.RunWithCounters(ctx, cfg)
+	return err
+}
+
+// RunWithCounters runs the job like Run and returns its user counter totals.
+func (LegacyRunner) RunWithCounters(ctx context.Context, cfg MapReduceRunConfig) (master.Counters, error) {
 	if len(cfg.Files) == 0 {
-		return nil
+		return nil, nil
 	}
 	if cfg.PluginPath == "" {
-		return fmt.Errorf("plugin path is required")
+		return nil, fmt.Errorf("plugin path is required")
 	}
 	if cfg.Reducers <= 0 {
-		return fmt.Errorf("reducers must be > 0")
+		return nil, fmt.Errorf("reducers must be > 0")
 	}
 	if cfg.Workers <= 0 {
-		return fmt.Errorf("workers must be > 0")
+		return nil, fmt.Errorf("workers must be > 0")
 	}
 	if cfg.Port <= 0 {
 		cfg.Port = 10000
 	}
 	if err := ctx.Err(); err != nil {
-		return err
+		return nil, err
 	}
 
 	legacyRuntimeMu.Lock()
 	defer legacyRuntimeMu.Unlock()
 
-	done := make(chan error, 1)
+	type result struct {
+		counters master.Counters
+		err      error
+	}
+	done := make(chan result, 1)
 	masterAddr := ":" + strconv.Itoa(cfg.Port)
 	go func() {
-		done <- mapreduce.StartSingleMachineJobWithAddr(cfg.Files, cfg.PluginPath, cfg.Reducers, cfg.Workers, cfg.InRAM, masterAddr)
+		counters, err := mapreduce.RunSingleMachineJob(cfg.Files, cfg.PluginPath, cfg.Reducers, cfg.Workers, cfg.InRAM, masterAddr,
+			master.SideInputs{Params: cfg.Params, CacheFiles: cfg.CacheFiles})
+		done <- result{counters, err}
 	}()
 
 	var canceled bool
 	for {
 		select {
-		case err := <-done:
-			if err != nil {
-				return err
+		case res := <-done:
+			if res.err != nil {
+				return nil, res.err
 			}
 			if canceled {
-				return ctx.Err()
+				return nil, ctx.Err()
 			}
-			return nil
+			return res.counters, nil
 		case <-ctx.Done():
 			canceled = true
 		}
@@ -0,0 +1,37 @@
+package batch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/emptyOVO/mrkit-go/master"
+)
+
+type plainRunner struct{ runs *int }
+
+func (r plainRunner) Run(context.Context, MapReduceRunConfig) error {
+	*r.runs++
+	return nil
+}
+
+type countingRunner struct{ plainRunner }
+
+func (countingRunner) RunWithCounters(context.Context, MapReduceRunConfig) (master.Counters, error) {
+	return master.Counters{"input": {"rows": 3}}, nil
+}
+
+func TestRunMapReduceWithCounters(t *testing.T) {
+	defer SetDefaultRunner(DefaultRunner())
+
+	var runs int
+	SetDefaultRunner(plainRunner{&runs})
+	if counters, err := RunMapReduceWithCounters(context.Background(), MapReduceRunConfig{}); err != nil || counters != nil || runs != 1 {
+		t.Errorf("a plain runner should run the job without counters, got %v, %v after %v runs", counters, err, runs)
+	}
+
+	SetDefaultRunner(countingRunner{plainRunner{&runs}})
+	counters, err := RunMapReduceWithCounters(context.Background(), MapReduceRunConfig{})
+	if err != nil || counters["input"]["rows"] != 3 || runs != 1 {
+		t.Errorf("expect the counters of a CountersRunner, got %v, %v", counters, err)
+	}
+}
@@ -0,0 +1,90 @@
+package mapreduce
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/emptyOVO/mrkit-go/master"
+	"github.com/emptyOVO/mrkit-go/rpc"
+	"google.golang.org/grpc"
+)
+
+// ServeMaster runs a long-lived master at masterAddr. Workers started with
+// StartWorker register once and serve every job submitted through RunJob.
+func ServeMaster(masterAddr string) error {
+	return master.ServeMaster(masterAddr, master.Options{Journal: JournalPath, Resume: Resume})
+}
+
+// RunJob submits a job to a master started with ServeMaster and waits until
+// it is done. It returns the job ID, and a *master.JobError if the job failed.
+func RunJob(ctx context.Context, masterAddr string, spec master.JobSpec) (string, error) {
+	in := &rpc.JobSpec{
+		Id:          spec.ID,
+		App:         spec.App,
+		NReduce:     int64(spec.NReduce),
+		OutputDir:   spec.OutputDir,
+		Compression: spec.Compression,
+		Partitioner: spec.Partitioner,
+		InputFormat: spec.InputFormat,
+		Params:      spec.Params,
+	}
+	// Workers resolve paths on their own, so send absolute ones.
+	for _, s := range spec.Files {
+		f, _ := filepath.Abs(s)
+		in.Files = append(in.Files, f)
+		if host, ok := spec.Hosts[s]; ok {
+			if in.Hosts == nil {
+				in.Hosts = make(map[string]string)
+			}
+			in.Hosts[f] = host
+		}
+	}
+	if spec.Plugin != "" {
+		in.Plugin, _ = filepath.Abs(spec.Plugin)
+	}
+	if spec.OutputDir != "" {
+		in.OutputDir, _ = filepath.Abs(spec.OutputDir)
+	}
+	// The master serves the cache files, resolve them the same way.
+	for _, s := range spec.CacheFiles {
+		f, _ := filepath.Abs(s)
+		in.CacheFiles = append(in.CacheFiles, f)
+	}
+
+	conn, err := grpc.DialContext(ctx, masterAddr, grpc.WithInsecure())
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	client := rpc.NewMasterClient(conn)
+
+	job, err := client.SubmitJob(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	info, err := client.WaitJob(ctx, job)
+	if err != nil {
+		return job.Id, err
+	}
+	if f := info.Failure; f != nil {
+		return job.Id, &master.JobError{
+			JobID:     info.Id,
+			Kind:      f.Kind,
+			Task:      int(f.Task),
+			Attempts:  int(f.Attempts),
+			LastError: f.Error,
+		}
+	}
+	return job.Id, nil
+}
+
+// JobStatus asks the master at masterAddr for the progress of a job. An empty
+// jobID picks the job the master is working on.
+func JobStatus(ctx context.Context, masterAddr string, jobID string) (*rpc.JobStatus, error) {
+	conn, err := grpc.DialContext(ctx, masterAddr, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return rpc.NewMasterClient(conn).GetJobStatus(ctx, &rpc.JobInfo{Id: jobID})
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/emptyOVO/mrkit-go/batch"
+	"github.com/emptyOVO/mrkit-go/master"
 )
 
 func getenvInt(name string, d int) int {
@@ -42,8 +43,13 @@ func main() {
 	plugin := flag.String("plugin", filepath.Join("cmd", "agg.so"), "plugin .so path")
 	configPath := flag.String("config", "", "Flow config file path (JSON)")
 	checkOnly := flag.Bool("check", false, "Validate flow config schema only (requires -config)")
+	masterAddr := flag.String("master", "", "Run jobs on the worker pool of a long-lived master at this address")
 	flag.Parse()
 
+	if *masterAddr != "" {
+		batch.SetDefaultRunner(batch.ClusterRunner{MasterAddr: *masterAddr})
+	}
+
 	if *configPath != "" {
 		cfg, err := loadFlowConfig(*configPath)
 		must(err)
@@ -56,11 +62,14 @@ func main() {
 		defer cancel()
 		switch *mode {
 		case "pipeline":
-			must(batch.RunFlow(ctx, cfg))
+			counters, err := batch.RunFlowWithCounters(ctx, cfg)
+			must(err)
+			printCounters(counters)
 			fmt.Println("flow done")
 		case "benchmark":
 			result, err := batch.RunFlowBenchmark(ctx, cfg)
 			must(err)
+			printCounters(result.Counters)
 			fmt.Printf("source=%s transform=%s sink=%s total=%s\n", result.SourceDuration, result.TransformDuration, result.SinkDuration, result.TotalDuration)
 		default:
 			must(fmt.Errorf("mode %s is not supported with -config (use pipeline|benchmark)", *mode))
@@ -119,7 +128,7 @@ func main() {
 
 	switch *mode {
 	case "pipeline":
-		err := batch.RunPipeline(ctx, batch.PipelineConfig{
+		counters, err := batch.RunPipelineWithCounters(ctx, batch.PipelineConfig{
 			DB:         baseDB,
 			SourceDB:   sourceDB,
 			SinkDB:     targetDB,
@@ -132,6 +141,7 @@ func main() {
 			Port:       getenvInt("MR_PORT", 10000),
 		})
 		must(err)
+		printCounters(counters)
 		fmt.Println("pipeline done")
 	case "prepare":
 		dbc, err := batch.OpenForApp(ctx, sourceDB)
@@ -184,12 +194,20 @@ func main() {
 			},
 		})
 		must(err)
+		printCounters(result.Counters)
 		fmt.Printf("prepare=%s pipeline=%s validate=%s total=%s\n", result.PrepareDuration, result.PipelineDuration, result.ValidateDuration, result.TotalDuration)
 	default:
 		must(fmt.Errorf("unsupported mode: %s", *mode))
 	}
 }
 
+// printCounters prints one "counter group.name=value" line per user counter.
+func printCounters(counters master.Counters) {
+	for _, c := range counters.ToRPC() {
+		fmt.Printf("counter %s.%s=%d\n", c.Group, c.Name, c.Value)
+	}
+}
+
 func getenvDefault(name, d string) string {
 	v := os.Getenv(name)
 	if v == "" {
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	mp "github.com/emptyOVO/mrkit-go"
+)
+
+func main() {
+	if err := mp.ServeMaster(mp.ParseServeArg()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
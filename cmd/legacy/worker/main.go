@@ -1,9 +1,16 @@
 package main
 
 import (
+	"os"
+
 	mp "github.com/emptyOVO/mrkit-go"
+	log "github.com/sirupsen/logrus"
 )
 
 func main() {
-	mp.StartWorker(mp.ParseArg())
+	input, plugin, nReducer, nWorker, inRAM := mp.ParseArg()
+	if err := mp.StartWorkerWithAddr(input, plugin, nReducer, nWorker, inRAM, mp.MasterIP); err != nil {
+		log.Error("[Worker] ", err)
+		os.Exit(1)
+	}
 }
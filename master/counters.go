@@ -0,0 +1,79 @@
+package master
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+// Counters are user counter values by group and name, as incremented by the
+// plugin functions with MrContext.IncrCounter.
+type Counters map[string]map[string]int64
+
+// CountersFromRPC collects counters received from a worker or the master.
+func CountersFromRPC(in []*rpc.Counter) Counters {
+	c := make(Counters)
+	for _, counter := range in {
+		c.Add(counter.Group, counter.Name, counter.Value)
+	}
+	return c
+}
+
+// Add adds delta to the counter name of group.
+func (c Counters) Add(group string, name string, delta int64) {
+	if c[group] == nil {
+		c[group] = make(map[string]int64)
+	}
+	c[group][name] += delta
+}
+
+// Merge adds every counter of other to c.
+func (c Counters) Merge(other Counters) {
+	for group, names := range other {
+		for name, value := range names {
+			c.Add(group, name, value)
+		}
+	}
+}
+
+// ToRPC returns the counters sorted by group and name.
+func (c Counters) ToRPC() []*rpc.Counter {
+	var ret []*rpc.Counter
+	for group, names := range c {
+		for name, value := range names {
+			ret = append(ret, &rpc.Counter{Group: group, Name: name, Value: value})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Group != ret[j].Group {
+			return ret[i].Group < ret[j].Group
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// String formats the counters as "group.name=value" separated by spaces,
+// sorted by group and name.
+func (c Counters) String() string {
+	parts := []string{}
+	for _, counter := range c.ToRPC() {
+		parts = append(parts, fmt.Sprintf("%v.%v=%v", counter.Group, counter.Name, counter.Value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// counters sums the counters of the winning attempts of the tasks of the
+// job. Each task keeps the counters of the attempt that completed it, so
+// failed, lost and speculative attempts are never counted.
+func (job *Job) counters() Counters {
+	total := make(Counters)
+	for _, ts := range append(job.mapStatuses(), job.reduceStatuses()...) {
+		if ts.TaskState == TASK_COMPLETED {
+			total.Merge(ts.Counters)
+		}
+	}
+	return total
+}
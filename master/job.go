@@ -0,0 +1,375 @@
+package master
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// JobSpec describes a job submitted to the master.
+type JobSpec struct {
+	// ID names the job, a random one is used when empty.
+	ID    string
+	Files []string
+	// Plugin is the .so file with the Map and Reduce functions. Empty runs
+	// the plugin the workers were started with.
+	Plugin string
+	// App names job functions compiled into the worker binaries and
+	// registered with worker.Register. It is used instead of Plugin.
+	App     string
+	NReduce int
+	// OutputDir receives mr-out-<reducer>.txt, the worker directory when empty.
+	OutputDir string
+	// Hosts maps input files to the host storing them, spelled the way its
+	// workers register. Map tasks are placed on that host when possible.
+	Hosts map[string]string
+	// Compression is the codec of the intermediate files and the shuffle:
+	// "none", "gzip" or "flate". Empty uses MR_COMPRESSION, and no
+	// compression if that is unset.
+	Compression string
+	// Partitioner is "range" to sample the map output keys before the map
+	// phase and give each reducer a key range, so that the outputs read in
+	// reducer order are globally sorted. Empty uses MR_PARTITIONER, and hash
+	// partitioning if that is unset.
+	Partitioner string
+	// InputFormat is the record reader of the input files: "text", "tsv",
+	// "csv", "jsonl", "gzip" or "whole". Empty uses MR_INPUT_FORMAT, and
+	// text if that is unset. Splits are cut on record boundaries.
+	InputFormat string
+	SideInputs
+}
+
+// compressions are the intermediate data codecs the workers implement.
+var compressions = map[string]bool{"": true, "none": true, "gzip": true, "flate": true}
+
+// inputFormats are the record readers the workers implement. "" is text.
+var inputFormats = map[string]bool{"": true, "text": true, "tsv": true, "csv": true, "jsonl": true, "gzip": true, "whole": true}
+
+// Job is one map-reduce job. A master runs any number of jobs over the same
+// workers, handing out their tasks in submission order.
+type Job struct {
+	ID          string
+	Plugin      string
+	App         string
+	OutputDir   string
+	MapTasks    []MapTaskInfo
+	ReduceTasks []ReduceTaskInfo
+	numReducer  int
+	phase       int
+	done        chan bool
+	err         *JobError
+	// failures counts the failed attempts of each worker. Workers with more
+	// than MR_WORKER_MAX_FAILURES get no more tasks of the job.
+	failures    map[string]int
+	Locality    Locality
+	Compression string
+	Partitioner string
+	InputFormat string
+	SideInputs
+	// cacheFiles are the names and sizes of the cache files for the workers.
+	cacheFiles []*rpc.CacheFile
+	// SplitPoints are the key ranges of the reducers of a range partitioned
+	// job, picked from the samples of the map tasks.
+	SplitPoints []string
+	samples     map[int][]string
+
+	// fetchFailures counts the fetch failures of each reduce task on the
+	// output of each map task, keyed by {map, reduce}.
+	fetchFailures map[[2]int]int
+}
+
+// JobError tells why a job failed: one of its tasks kept failing until it ran
+// out of attempts, or no worker is left to run it.
+type JobError struct {
+	JobID string
+	// Kind is "Map" or "Reduce".
+	Kind      string
+	Task      int
+	Attempts  int
+	LastError string
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("job %v failed: %v task %v failed %v times, last error: %v", e.JobID, e.Kind, e.Task, e.Attempts, e.LastError)
+}
+
+func newJob(spec JobSpec) *Job {
+	id := spec.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
+	return &Job{
+		ID:          id,
+		Plugin:      spec.Plugin,
+		App:         spec.App,
+		OutputDir:   spec.OutputDir,
+		Compression: spec.Compression,
+		Partitioner: spec.Partitioner,
+		InputFormat: spec.InputFormat,
+		SideInputs:  spec.SideInputs,
+		samples:     make(map[int][]string),
+		ReduceTasks: newReduceTasks(spec.NReduce),
+		numReducer:  spec.NReduce,
+		phase:       PHASE_SETUP,
+		done:        make(chan bool),
+		failures:    make(map[string]int),
+
+		fetchFailures: make(map[[2]int]int),
+	}
+}
+
+func (job *Job) finished() bool {
+	return job.phase == PHASE_DONE || job.phase == PHASE_FAILED
+}
+
+func (job *Job) blacklisted(workerUUID string) bool {
+	return job.failures[workerUUID] > intFromEnv("MR_WORKER_MAX_FAILURES", 3)
+}
+
+// fail gives up the job. Attempts still running are ignored from now on.
+func (job *Job) fail(err *JobError) {
+	if job.finished() {
+		return
+	}
+	job.phase = PHASE_FAILED
+	job.err = err
+	close(job.done)
+	log.Error("[Master] ", err)
+}
+
+func (job *Job) mapStatuses() []*TaskStatus {
+	ret := make([]*TaskStatus, len(job.MapTasks))
+	for i := range job.MapTasks {
+		ret[i] = &job.MapTasks[i].TaskStatus
+	}
+	return ret
+}
+
+func (job *Job) reduceStatuses() []*TaskStatus {
+	ret := make([]*TaskStatus, len(job.ReduceTasks))
+	for i := range job.ReduceTasks {
+		ret[i] = &job.ReduceTasks[i].TaskStatus
+	}
+	return ret
+}
+
+func (job *Job) statuses(reduce bool) []*TaskStatus {
+	if reduce {
+		return job.reduceStatuses()
+	}
+	return job.mapStatuses()
+}
+
+// findTask returns the kind and index of the task with the given UUID, or -1.
+func (job *Job) findTask(uuid string) (bool, int) {
+	if id := findTask(job.mapStatuses(), uuid); id >= 0 {
+		return false, id
+	}
+	return true, findTask(job.reduceStatuses(), uuid)
+}
+
+// runningTask returns the task with an attempt running on workerUUID, or -1.
+func (job *Job) runningTask(workerUUID string) (bool, int) {
+	if job.finished() {
+		return false, -1
+	}
+	for _, reduce := range []bool{false, true} {
+		for i, t := range job.statuses(reduce) {
+			if t.runningOn(workerUUID) {
+				return reduce, i
+			}
+		}
+	}
+	return false, -1
+}
+
+// nextTask returns a runnable task of the current phase for workerUUID on
+// host, or -1. Tasks whose data is stored on host go first. A task stored
+// elsewhere is left for its own host as long as idleOn says a worker there is
+// free to take it.
+func (job *Job) nextTask(workerUUID string, host string, idleOn func(host string) bool) (bool, int) {
+	if job.blacklisted(workerUUID) {
+		return false, -1
+	}
+	var reduce bool
+	switch job.phase {
+	case PHASE_SAMPLE, PHASE_MAP:
+		reduce = false
+	case PHASE_REDUCE:
+		reduce = true
+	default:
+		return false, -1
+	}
+	tasks := job.statuses(reduce)
+	if !boolFromEnv("MR_LOCALITY", true) {
+		return reduce, nextTask(tasks)
+	}
+
+	ret := -1
+	for i, t := range tasks {
+		if !t.runnable() {
+			continue
+		}
+		preferred := job.preferredHost(reduce, i)
+		if preferred == host {
+			return reduce, i
+		}
+		if ret < 0 && (preferred == "" || !idleOn(preferred)) {
+			ret = i
+		}
+	}
+	return reduce, ret
+}
+
+// timedOutTask returns a task of the current phase with an attempt that has
+// outlived MR_MAP_TASK_TIMEOUT_SEC or MR_REDUCE_TASK_TIMEOUT_SEC, and the
+// worker of that attempt, or -1.
+func (job *Job) timedOutTask() (bool, int, string) {
+	var reduce bool
+	var timeout time.Duration
+	switch job.phase {
+	case PHASE_SAMPLE, PHASE_MAP:
+		reduce, timeout = false, durationFromEnv("MR_MAP_TASK_TIMEOUT_SEC", 600*time.Second)
+	case PHASE_REDUCE:
+		reduce, timeout = true, durationFromEnv("MR_REDUCE_TASK_TIMEOUT_SEC", 600*time.Second)
+	default:
+		return false, -1, ""
+	}
+	for i, t := range job.statuses(reduce) {
+		if worker := t.timedOut(timeout); worker != "" {
+			return reduce, i, worker
+		}
+	}
+	return reduce, -1, ""
+}
+
+// speculativeTask returns a straggler of the current phase to back up on
+// workerUUID, or -1.
+func (job *Job) speculativeTask(workerUUID string) (bool, int) {
+	if job.blacklisted(workerUUID) {
+		return false, -1
+	}
+	switch job.phase {
+	case PHASE_SAMPLE, PHASE_MAP:
+		return false, speculativeTask(job.mapStatuses(), workerUUID)
+	case PHASE_REDUCE:
+		return true, speculativeTask(job.reduceStatuses(), workerUUID)
+	}
+	return false, -1
+}
+
+func (job *Job) taskToRPC(reduce bool, id int) *rpc.Task {
+	if reduce {
+		info := job.ReduceTasks[id].toRPC()
+		info.Id = int64(id)
+		info.JobId = job.ID
+		info.OutputDir = job.OutputDir
+		info.Compression = job.Compression
+		return &rpc.Task{Type: rpc.Task_REDUCE, Uuid: job.ReduceTasks[id].UUID, Reduce: info, Plugin: job.Plugin, App: job.App,
+			Params: job.Params, CacheFiles: job.cacheFiles, Attempt: int64(job.ReduceTasks[id].Launches)}
+	}
+	info := job.MapTasks[id].toRPC()
+	info.Id = int64(id)
+	info.JobId = job.ID
+	info.NReduce = int64(job.numReducer)
+	info.Compression = job.Compression
+	info.Partitioner = job.Partitioner
+	info.InputFormat = job.InputFormat
+	info.OutputDir = job.OutputDir
+	info.SplitPoints = job.SplitPoints
+	if job.phase == PHASE_SAMPLE {
+		info.SampleKeys = int64(intFromEnv("MR_SAMPLE_KEYS", 1000))
+		info.SampleBytes = int64(intFromEnv("MR_SAMPLE_BYTES", 1<<20))
+	}
+	return &rpc.Task{Type: rpc.Task_MAP, Uuid: job.MapTasks[id].UUID, Map: info, Plugin: job.Plugin, App: job.App,
+		Params: job.Params, CacheFiles: job.cacheFiles, Attempt: int64(job.MapTasks[id].Launches)}
+}
+
+func taskKind(reduce bool) string {
+	if reduce {
+		return "Reduce"
+	}
+	return "Map"
+}
+
+// advancePhase moves the job forward once every task of the current phase has
+// completed. Reducers are handed the intermediate files of all map tasks.
+func (job *Job) advancePhase() {
+	if job.phase == PHASE_SAMPLE && allCompleted(job.mapStatuses()) {
+		job.finishSampling()
+	}
+	if job.phase == PHASE_MAP && allCompleted(job.mapStatuses()) {
+		for r := range job.ReduceTasks {
+			job.ReduceTasks[r].IMDs = nil
+			for _, mapTask := range job.MapTasks {
+				job.ReduceTasks[r].IMDs = append(job.ReduceTasks[r].IMDs, mapTask.IMDs[r])
+			}
+		}
+		job.phase = PHASE_REDUCE
+		log.Info(fmt.Sprintf("[Master] Job %v map phase done, start Reduce phase", job.ID))
+	}
+	if job.phase == PHASE_REDUCE && allCompleted(job.reduceStatuses()) {
+		job.phase = PHASE_DONE
+		close(job.done)
+		log.Info(fmt.Sprintf("[Master] Job %v reduce phase done", job.ID))
+		log.Info(fmt.Sprintf("[Master] Job %v summary: %v", job.ID, job.Locality))
+		if counters := job.counters(); len(counters) > 0 {
+			log.Info(fmt.Sprintf("[Master] Job %v counters: %v", job.ID, counters))
+		}
+	}
+}
+
+var phaseNames = map[int]string{
+	PHASE_SETUP:  "setup",
+	PHASE_SAMPLE: "sample",
+	PHASE_MAP:    "map",
+	PHASE_REDUCE: "reduce",
+	PHASE_DONE:   "done",
+	PHASE_FAILED: "failed",
+}
+
+// toRPC reports the progress of the job and each of its tasks.
+func (job *Job) toRPC() *rpc.JobStatus {
+	ret := &rpc.JobStatus{Id: job.ID, Phase: phaseNames[job.phase], Failure: job.err.toRPC(), Counters: job.counters().ToRPC()}
+	for _, reduce := range []bool{false, true} {
+		for i, t := range job.statuses(reduce) {
+			p := t.toRPC()
+			p.Kind = taskKind(reduce)
+			p.Id = int64(i)
+			p.Bytes = job.inputBytes(reduce, i)
+			ret.Tasks = append(ret.Tasks, p)
+		}
+	}
+	return ret
+}
+
+// inputBytes returns the size of the split a map task reads, or of the
+// intermediate files a reduce task fetches.
+func (job *Job) inputBytes(reduce bool, id int) int64 {
+	var n int64
+	if reduce {
+		for _, imd := range job.ReduceTasks[id].IMDs {
+			n += imd.Size
+		}
+		return n
+	}
+	for _, f := range job.MapTasks[id].Files {
+		n += int64(f.To - f.From)
+	}
+	return n
+}
+
+func (e *JobError) toRPC() *rpc.JobFailure {
+	if e == nil {
+		return nil
+	}
+	return &rpc.JobFailure{
+		Kind:     e.Kind,
+		Task:     int64(e.Task),
+		Attempts: int64(e.Attempts),
+		Error:    e.LastError,
+	}
+}
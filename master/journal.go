@@ -0,0 +1,250 @@
+package master
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Journal record types. Each record is one state transition of the master,
+// written before the transition becomes visible to any worker.
+const (
+	journalJob          = "job"
+	journalRegister     = "register"
+	journalAssign       = "assign"
+	journalBackup       = "backup"
+	journalReport       = "report"
+	journalLost         = "lost"
+	journalTimeout      = "timeout"
+	journalFetchFailure = "fetch_failure"
+)
+
+type journalRecord struct {
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+	Job    string    `json:"job,omitempty"`
+	Worker string    `json:"worker,omitempty"`
+	IP     string    `json:"ip,omitempty"`
+	// Reduce tells whether Task indexes ReduceTasks instead of MapTasks.
+	Reduce    bool     `json:"reduce,omitempty"`
+	Task      int      `json:"task,omitempty"`
+	Result    bool     `json:"result,omitempty"`
+	Filenames []string `json:"filenames,omitempty"`
+	Sizes     []int64  `json:"sizes,omitempty"`
+	Records   int64    `json:"records,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	// SampleKeys are the keys reported by a sample attempt.
+	SampleKeys []string `json:"sample_keys,omitempty"`
+	Counters   Counters `json:"counters,omitempty"`
+	// CustomCompare is set when the sampled plugin has a Compare function.
+	CustomCompare bool `json:"custom_compare,omitempty"`
+	// Job settings and task layout, only set on the job record.
+	Plugin      string            `json:"plugin,omitempty"`
+	App         string            `json:"app,omitempty"`
+	OutputDir   string            `json:"output_dir,omitempty"`
+	Compression string            `json:"compression,omitempty"`
+	Partitioner string            `json:"partitioner,omitempty"`
+	InputFormat string            `json:"input_format,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	CacheFiles  []string          `json:"cache_files,omitempty"`
+	MapFiles    [][]FileInfo      `json:"map_files,omitempty"`
+	MapUUIDs    []string          `json:"map_uuids,omitempty"`
+	ReduceUUIDs []string          `json:"reduce_uuids,omitempty"`
+}
+
+type journal struct {
+	file *os.File
+	// mux guards the writes and written, the number of records written.
+	mux     sync.Mutex
+	written int
+	// syncMux serializes fsyncs; synced is the number of records they made
+	// durable.
+	syncMux sync.Mutex
+	synced  int
+}
+
+// openJournal opens the journal for appending. Unless resuming, any journal
+// left by a previous job is discarded.
+func openJournal(path string, resume bool) (*journal, error) {
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume {
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{file: f}, nil
+}
+
+// append writes a record, which is durable once a later sync returns.
+func (j *journal) append(rec journalRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	if _, err := j.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	j.written++
+	return nil
+}
+
+// sync makes every record written so far durable. Callers waiting for an
+// fsync in progress share the next one.
+func (j *journal) sync() error {
+	j.syncMux.Lock()
+	defer j.syncMux.Unlock()
+	j.mux.Lock()
+	written := j.written
+	j.mux.Unlock()
+	if written <= j.synced {
+		return nil
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	j.synced = written
+	return nil
+}
+
+func (j *journal) close() error {
+	if err := j.sync(); err != nil {
+		j.file.Close()
+		return err
+	}
+	return j.file.Close()
+}
+
+func readJournal(path string) ([]journalRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []journalRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1<<20), 64<<20)
+	for scanner.Scan() {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn write at the tail is expected if the master died mid-record.
+			log.Warn(fmt.Sprintf("[Master] Skip broken journal record: %v", err))
+			break
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// record appends a state transition to the journal, if one is configured.
+// It is called with ms.mux held, so that records keep the order of the
+// transitions.
+func (ms *Master) record(rec journalRecord) {
+	if ms.journal == nil {
+		return
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	if err := ms.journal.append(rec); err != nil {
+		log.Error("[Master] Write journal failed: ", err)
+	}
+}
+
+// syncJournal makes the recorded transitions durable. RPCs call it after
+// releasing ms.mux and before replying, so no worker learns of a transition
+// that a restarted master would not replay, and the fsync does not hold up
+// other RPCs.
+func (ms *Master) syncJournal() {
+	if ms.journal == nil {
+		return
+	}
+	if err := ms.journal.sync(); err != nil {
+		log.Error("[Master] Sync journal failed: ", err)
+	}
+}
+
+// replayJournal rebuilds the job state from the journal of a previous master.
+// Workers found in the journal are assumed alive until heartbeats say
+// otherwise, which re-queues whatever they held. Workers the previous master
+// found dead stay so until a heartbeat brings them back.
+func (ms *Master) replayJournal(path string) error {
+	records, err := readJournal(path)
+	if err != nil {
+		return err
+	}
+
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
+	for _, rec := range records {
+		switch rec.Type {
+		case journalRegister:
+			ms.addWorker(rec.Worker, rec.IP)
+			continue
+		case journalLost:
+			if id := ms.workerIndex(rec.Worker); id >= 0 {
+				ms.Workers[id].SetState(WORKER_UNKNOWN)
+			}
+			ms.recoverWorkerTasks(rec.Worker)
+			continue
+		case journalJob:
+			job := newJob(JobSpec{
+				ID:          rec.Job,
+				Plugin:      rec.Plugin,
+				App:         rec.App,
+				NReduce:     len(rec.ReduceUUIDs),
+				OutputDir:   rec.OutputDir,
+				Compression: rec.Compression,
+				Partitioner: rec.Partitioner,
+				InputFormat: rec.InputFormat,
+				SideInputs:  SideInputs{Params: rec.Params, CacheFiles: rec.CacheFiles},
+			})
+			if job.cacheFiles, err = cacheFileInfo(rec.CacheFiles); err != nil {
+				return err
+			}
+			job.MapTasks = make([]MapTaskInfo, len(rec.MapUUIDs))
+			for i := range rec.MapUUIDs {
+				job.MapTasks[i].TaskStatus = newTaskStatus(rec.MapUUIDs[i])
+				job.MapTasks[i].Files = rec.MapFiles[i]
+			}
+			for i := range rec.ReduceUUIDs {
+				job.ReduceTasks[i].TaskStatus = newTaskStatus(rec.ReduceUUIDs[i])
+			}
+			job.phase = job.firstPhase()
+			ms.Jobs = append(ms.Jobs, job)
+		}
+
+		job := ms.findJob(rec.Job)
+		if job == nil {
+			continue
+		}
+		switch rec.Type {
+		case journalAssign:
+			task := job.statuses(rec.Reduce)[rec.Task]
+			task.assign(rec.Worker)
+			task.StartTime = rec.Time
+		case journalBackup:
+			task := job.statuses(rec.Reduce)[rec.Task]
+			task.assignBackup(rec.Worker)
+			task.BackupStart = rec.Time
+		case journalReport:
+			ms.applyReport(job, rec)
+		case journalTimeout:
+			ms.applyTimeout(job, rec.Reduce, rec.Task, rec.Worker)
+		case journalFetchFailure:
+			ms.applyFetchFailure(job, rec.Task, rec.Worker, rec.IP, rec.Filenames[0])
+		}
+		job.advancePhase()
+	}
+	log.Info(fmt.Sprintf("[Master] Replayed %v journal records, %v jobs and %v workers known", len(records), len(ms.Jobs), len(ms.Workers)))
+	return nil
+}
@@ -0,0 +1,167 @@
+package master
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/peer"
+)
+
+// Locality counts where the committed tasks of a job ran relative to their
+// data. Map tasks without a known input host count as MapAny.
+type Locality struct {
+	MapLocal     int
+	MapRemote    int
+	MapAny       int
+	ReduceLocal  int
+	ReduceRemote int
+	// ShuffleBytes is the intermediate data read by reducers, LocalShuffleBytes
+	// the part of it stored on the host of the reducer.
+	ShuffleBytes      int64
+	LocalShuffleBytes int64
+}
+
+func (l Locality) String() string {
+	return fmt.Sprintf("map tasks %v data-local, %v remote, %v without input host; reduce tasks %v local, %v remote; %v of %v shuffle bytes local",
+		l.MapLocal, l.MapRemote, l.MapAny, l.ReduceLocal, l.ReduceRemote, l.LocalShuffleBytes, l.ShuffleBytes)
+}
+
+// hostOf returns the host part of a worker address.
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// withPeerHost fills in the host of a worker address registered as ":port"
+// with the host the worker connected from, so that other hosts can reach it
+// and tasks can be placed next to its data.
+func withPeerHost(ctx context.Context, addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return addr
+	}
+	if host, _, err = net.SplitHostPort(p.Addr.String()); err != nil {
+		return addr
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// preferredHost returns the host storing most input bytes of the task, or ""
+// if no input host is known.
+func (mt *MapTaskInfo) preferredHost() string {
+	bytes := make(map[string]int64)
+	for _, f := range mt.Files {
+		if f.Host != "" {
+			bytes[f.Host] += int64(f.To - f.From)
+		}
+	}
+	return largest(bytes)
+}
+
+// preferredHost returns the host storing most intermediate bytes of the
+// partition, or "" before the map phase is over.
+func (rt *ReduceTaskInfo) preferredHost() string {
+	bytes := make(map[string]int64)
+	for _, imd := range rt.IMDs {
+		bytes[hostOf(imd.IP)] += imd.weight()
+	}
+	return largest(bytes)
+}
+
+// weight is the size of the file, or 1 if the worker did not report it.
+func (imd IMDInfo) weight() int64 {
+	if imd.Size > 0 {
+		return imd.Size
+	}
+	return 1
+}
+
+func largest(bytes map[string]int64) string {
+	ret := ""
+	var max int64 = -1
+	for host, n := range bytes {
+		if n > max || n == max && host < ret {
+			ret, max = host, n
+		}
+	}
+	return ret
+}
+
+func (job *Job) preferredHost(reduce bool, id int) string {
+	if reduce {
+		return job.ReduceTasks[id].preferredHost()
+	}
+	return job.MapTasks[id].preferredHost()
+}
+
+// countLocality records where the committed attempt of a task ran.
+func (job *Job) countLocality(reduce bool, id int, host string) {
+	l := &job.Locality
+	preferred := job.preferredHost(reduce, id)
+	if !reduce {
+		switch preferred {
+		case "":
+			l.MapAny++
+		case host:
+			l.MapLocal++
+		default:
+			l.MapRemote++
+		}
+		return
+	}
+	if preferred == host {
+		l.ReduceLocal++
+	} else {
+		l.ReduceRemote++
+	}
+	for _, imd := range job.ReduceTasks[id].IMDs {
+		l.ShuffleBytes += imd.Size
+		if hostOf(imd.IP) == host {
+			l.LocalShuffleBytes += imd.Size
+		}
+	}
+}
+
+// idleHosts returns the hosts with a worker free to pick up a task of job
+// soon: it is healthy, not blacklisted for the job and not busy.
+func (ms *Master) idleHosts(job *Job, busy map[string]bool) map[string]bool {
+	hosts := make(map[string]bool)
+	for i := range ms.Workers {
+		w := &ms.Workers[i]
+		if w.Broken() || job.blacklisted(w.UUID) || busy[w.UUID] {
+			continue
+		}
+		hosts[hostOf(w.getIP())] = true
+	}
+	return hosts
+}
+
+// busyWorkers returns the workers running an attempt of a task of any job.
+func (ms *Master) busyWorkers() map[string]bool {
+	busy := make(map[string]bool)
+	for _, job := range ms.runningJobs() {
+		if job.finished() {
+			continue
+		}
+		for _, reduce := range []bool{false, true} {
+			for _, t := range job.statuses(reduce) {
+				if t.TaskState != TASK_INPROGRESS {
+					continue
+				}
+				busy[t.WorkerUUID] = true
+				if t.BackupUUID != "" {
+					busy[t.BackupUUID] = true
+				}
+			}
+		}
+	}
+	return busy
+}
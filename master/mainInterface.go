@@ -20,35 +20,131 @@ const (
 	WORKER_UNKNOWN
 )
 
+const (
+	PHASE_SETUP int = iota
+	PHASE_SAMPLE
+	PHASE_MAP
+	PHASE_REDUCE
+	PHASE_DONE
+	PHASE_FAILED
+)
+
 func init() {
 	log.SetLevel(log.TraceLevel)
 }
 
-func StartMaster(files []string, nWorker int, nReduce int, addr string) {
+// Options holds the optional master settings.
+type Options struct {
+	// Journal is the path of the state journal. Empty disables journaling.
+	Journal string
+	// Resume replays Journal and continues the job it describes instead of
+	// starting a new one.
+	Resume bool
+	// Counters, if not nil, receives the user counter totals of the job once
+	// it is done.
+	Counters Counters
+	// SideInputs are given to the tasks of the job.
+	SideInputs SideInputs
+}
+
+func StartMaster(files []string, nWorker int, nReduce int, addr string) error {
+	return StartMasterWithOptions(files, nWorker, nReduce, addr, Options{})
+}
+
+// StartMasterWithOptions runs a single job and stops the workers once it is
+// over. It returns a *JobError if the job failed.
+func StartMasterWithOptions(files []string, nWorker int, nReduce int, addr string, opts Options) error {
+	ms := NewMaster(nWorker, nReduce).(*Master)
+	ms.exitWhenDone = true
+	resumed, err := ms.useJournal(opts)
+	if err != nil {
+		log.Panic(err)
+	}
+	if ms.journal != nil {
+		defer ms.journal.close()
+	}
+
 	// start gRPC server
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		log.Panic(err)
 	}
-	ms := NewMaster(nWorker, nReduce)
-	baseServer := grpc.NewServer()
-	rpc.RegisterMasterServer(baseServer, ms)
+	baseServer := ms.newServer()
 	go baseServer.Serve(listener)
-
 	log.Info("[Master] Master gRPC server start")
 
-	// Check the worker is enough
-	ms.(*Master).waitForEnoughWorker()
-	go ms.(*Master).PeriodicHealthCheck()
+	var jobErr error
+	if resumed {
+		log.Info("[Master] Resume job from journal ", opts.Journal)
+	} else {
+		// Split input file, workers pull the tasks as soon as they register
+		_, jobErr = ms.Submit(JobSpec{Files: files, NReduce: nReduce, SideInputs: opts.SideInputs})
+	}
+	if jobErr == nil {
+		jobErr = ms.waitForJobs()
+	}
+	if opts.Counters != nil {
+		ms.mux.Lock()
+		for _, job := range ms.Jobs {
+			opts.Counters.Merge(job.counters())
+		}
+		ms.mux.Unlock()
+	}
 
-	// Split input file (100,000 lines per chunk)
-	ms.(*Master).distributeWork(files)
+	ms.endWorkers()
 
-	ms.(*Master).distributeMapTask()
+	close(ms.quit)
+	baseServer.Stop()
+	return jobErr
+}
 
-	ms.(*Master).distributeReduceTask()
+// ServeMaster runs a long-lived master at addr. Jobs are submitted through
+// the SubmitJob RPC and share the workers registered with the master, which
+// keep waiting for work between jobs.
+func ServeMaster(addr string, opts Options) error {
+	ms := NewMaster(0, 0).(*Master)
+	if _, err := ms.useJournal(opts); err != nil {
+		return err
+	}
+	if ms.journal != nil {
+		defer ms.journal.close()
+	}
 
-	ms.(*Master).endWorkers()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer close(ms.quit)
+	log.Info("[Master] Master service start")
+	return ms.newServer().Serve(listener)
+}
 
-	baseServer.Stop()
+// newServer registers the master on a new gRPC server and starts watching
+// worker health.
+func (ms *Master) newServer() *grpc.Server {
+	baseServer := grpc.NewServer()
+	rpc.RegisterMasterServer(baseServer, ms)
+	go ms.PeriodicHealthCheck()
+	return baseServer
+}
+
+// useJournal replays the journal when resuming and records every further
+// state transition to it. It reports whether a job was resumed.
+func (ms *Master) useJournal(opts Options) (bool, error) {
+	if opts.Journal == "" {
+		return false, nil
+	}
+	resumed := false
+	if opts.Resume {
+		if err := ms.replayJournal(opts.Journal); err != nil {
+			return false, err
+		}
+		resumed = len(ms.Jobs) > 0
+	}
+	j, err := openJournal(opts.Journal, resumed)
+	if err != nil {
+		return false, err
+	}
+	ms.journal = j
+	return resumed, nil
 }
@@ -7,15 +7,18 @@ import (
 )
 
 type MapTaskInfo struct {
-	Files     []FileInfo
-	TaskState int
-	UUID      string
+	TaskStatus
+	Files []FileInfo
+	// IMDs holds one intermediate file per reducer once the task completes.
+	IMDs []IMDInfo
 }
 
 type FileInfo struct {
 	FileName string
 	From     int
 	To       int
+	// Host stores the file, empty when unknown.
+	Host string
 }
 
 func (mt *MapTaskInfo) addFile(file string, from int, to int) {
@@ -29,19 +32,10 @@ func (mt *MapTaskInfo) addFile(file string, from int, to int) {
 
 func newMapTask() MapTaskInfo {
 	return MapTaskInfo{
-		UUID:      uuid.New().String(),
-		TaskState: TASK_IDLE,
+		TaskStatus: newTaskStatus(uuid.New().String()),
 	}
 }
 
-func newMapTasks(size int) []MapTaskInfo {
-	var ret []MapTaskInfo
-	for i := 0; i < size; i++ {
-		ret = append(ret, newMapTask())
-	}
-	return ret
-}
-
 func (mt *MapTaskInfo) toRPC() *rpc.MapInfo {
 	ret := &rpc.MapInfo{}
 
@@ -59,3 +53,13 @@ func (mt *MapTaskInfo) toRPC() *rpc.MapInfo {
 func (mt *MapTaskInfo) setState(state int) {
 	mt.TaskState = state
 }
+
+// produced reports whether filename on ip is one of the task outputs.
+func (mt *MapTaskInfo) produced(ip string, filename string) bool {
+	for _, imd := range mt.IMDs {
+		if imd.IP == ip && imd.FileName == filename {
+			return true
+		}
+	}
+	return false
+}
@@ -1,27 +1,34 @@
 package master
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"io"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/emptyOVO/mrkit-go/rpc"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Master struct {
-	Workers      []WorkerInfo
-	MapTasks     []MapTaskInfo
-	ReduceTasks  []ReduceTaskInfo
+	Workers []WorkerInfo
+	Jobs    []*Job
+	// firstRunning is the index of the oldest job still running. The jobs
+	// before it are all finished and no longer scanned for tasks.
+	firstRunning int
 	numWorkers   int
 	totalWorkers int
-	numReducer   int
-	enoughWorker chan bool
-	crashChan    chan string
+	// numReducer is the reducer count of jobs that do not set their own.
+	numReducer int
+	split      SplitPolicy
+	// exitWhenDone tells workers to exit once every job is done. A long-lived
+	// master keeps them waiting for the next job instead.
+	exitWhenDone bool
+	quit         chan bool
+	journal      *journal
 	mux          sync.Mutex
 	client       RpcClient
 	rpc.UnimplementedMasterServer
@@ -30,34 +37,44 @@ type Master struct {
 func NewMaster(nWorker int, nReduce int) rpc.MasterServer {
 
 	return &Master{
-		ReduceTasks:  newReduceTasks(nReduce),
 		numWorkers:   0,
 		totalWorkers: nWorker,
 		numReducer:   nReduce,
+		split:        splitPolicyFromEnv(),
+		quit:         make(chan bool),
 		client:       &workerClient{},
-		enoughWorker: make(chan bool, 1),
-		crashChan:    make(chan string, 100),
 	}
 }
 
 // gRPC functions
 
 func (ms *Master) WorkerRegister(ctx context.Context, in *rpc.WorkerInfo) (*rpc.RegisterResult, error) {
-	var num int
+	ip := withPeerHost(ctx, in.Ip)
 	ms.mux.Lock()
-	ms.Workers = append(ms.Workers, newWorker(in.Uuid, in.Ip))
-	ms.numWorkers++
-
-	num = ms.numWorkers
+	ms.record(journalRecord{Type: journalRegister, Worker: in.Uuid, IP: ip})
+	num := ms.addWorker(in.Uuid, ip)
 	ms.mux.Unlock()
+	ms.syncJournal()
 	log.Info("[Master] Worker register success")
 	return &rpc.RegisterResult{Result: true, Id: int64(num - 1)}, nil
 }
 
 func (ms *Master) UpdateIMDInfo(ctx context.Context, in *rpc.IMDInfo) (*rpc.UpdateResult, error) {
 	ms.mux.Lock()
+	job := ms.findJob(in.JobId)
+	if job == nil && in.JobId == "" && len(ms.Jobs) > 0 {
+		job = ms.Jobs[0]
+	}
+	if job == nil {
+		ms.mux.Unlock()
+		return nil, status.Errorf(codes.NotFound, "job %v not found", in.JobId)
+	}
+	if len(in.Filenames) != job.numReducer {
+		ms.mux.Unlock()
+		return nil, status.Errorf(codes.InvalidArgument, "got %v intermediate files for %v reducers", len(in.Filenames), job.numReducer)
+	}
 	for i, f := range in.Filenames {
-		ms.ReduceTasks[i].IMDs = append(ms.ReduceTasks[i].IMDs,
+		job.ReduceTasks[i].IMDs = append(job.ReduceTasks[i].IMDs,
 			IMDInfo{
 				IP:       ms.serviceDiscovey(in.Uuid),
 				FileName: f,
@@ -68,286 +85,534 @@ func (ms *Master) UpdateIMDInfo(ctx context.Context, in *rpc.IMDInfo) (*rpc.Upda
 	return &rpc.UpdateResult{Result: true}, nil
 }
 
-func (ms *Master) serviceDiscovey(uuid string) string {
-	var ip string
+// RequestTask hands the next runnable task to an idle worker. Jobs are served
+// in submission order, and a fresh task of any job goes before a backup copy
+// of a straggler. Workers asking while every remaining task is running are
+// told to wait and ask again. Every reply names the jobs the worker holds
+// intermediate files of that have ended, so that it can delete them.
+func (ms *Master) RequestTask(ctx context.Context, in *rpc.WorkerInfo) (*rpc.Task, error) {
+	defer ms.syncJournal()
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
 
-	for i := range ms.Workers {
-		if ms.Workers[i].UUID == uuid {
-			ip = ms.Workers[i].getIP()
+	wid := ms.workerIndex(in.Uuid)
+	if wid < 0 {
+		return nil, status.Errorf(codes.NotFound, "worker %v is not registered", in.Uuid)
+	}
+	task := ms.assignTask(wid)
+	task.EndedJobs = ms.endedJobs(in.Jobs)
+	return task, nil
+}
+
+// endedJobs returns the jobs of ids that are done or failed. Jobs the master
+// does not know of, such as those of a master that was not resumed, count as
+// ended too.
+func (ms *Master) endedJobs(ids []string) []string {
+	var ret []string
+	for _, id := range ids {
+		if job := ms.findJob(id); job == nil || job.finished() {
+			ret = append(ret, id)
 		}
 	}
+	return ret
+}
 
-	return ip
+// assignTask picks the task for worker wid, as described by RequestTask.
+func (ms *Master) assignTask(wid int) *rpc.Task {
+	uuid := ms.Workers[wid].UUID
+	if ms.Workers[wid].Broken() {
+		// Wait for the heartbeat to confirm the worker is healthy again.
+		return &rpc.Task{Type: rpc.Task_WAIT}
+	}
+
+	ms.expireTasks()
+
+	// A worker only asks for a task once it has reported the last one, so a
+	// task still running on it was assigned by a request whose reply was lost.
+	for _, job := range ms.runningJobs() {
+		if reduce, id := job.runningTask(uuid); id >= 0 {
+			return job.taskToRPC(reduce, id)
+		}
+	}
+
+	// The idle hosts are only worked out when a task prefers another host,
+	// and then once per job.
+	var busy map[string]bool
+	for _, job := range ms.runningJobs() {
+		var idle map[string]bool
+		idleOn := func(host string) bool {
+			if idle == nil {
+				if busy == nil {
+					busy = ms.busyWorkers()
+				}
+				idle = ms.idleHosts(job, busy)
+			}
+			return idle[host]
+		}
+		if reduce, id := job.nextTask(uuid, hostOf(ms.Workers[wid].getIP()), idleOn); id >= 0 {
+			ms.record(journalRecord{Type: journalAssign, Job: job.ID, Worker: uuid, Reduce: reduce, Task: id})
+			job.statuses(reduce)[id].assign(uuid)
+			log.Info(fmt.Sprintf("[Master] Assign %v task %v of job %v to %v", taskKind(reduce), id, job.ID, uuid))
+			return job.taskToRPC(reduce, id)
+		}
+	}
+	for _, job := range ms.runningJobs() {
+		if reduce, id := job.speculativeTask(uuid); id >= 0 {
+			ms.record(journalRecord{Type: journalBackup, Job: job.ID, Worker: uuid, Reduce: reduce, Task: id})
+			job.statuses(reduce)[id].assignBackup(uuid)
+			log.Info(fmt.Sprintf("[Master] Launch backup of %v task %v of job %v on %v", taskKind(reduce), id, job.ID, uuid))
+			return job.taskToRPC(reduce, id)
+		}
+	}
+	if ms.exitWhenDone && ms.allJobsDone() {
+		return &rpc.Task{Type: rpc.Task_EXIT}
+	}
+	return &rpc.Task{Type: rpc.Task_WAIT}
 }
 
-// Normal Functions
+// ReportTask records the outcome of a task attempt. A failed attempt puts the
+// task back in the queue. The first successful copy of a task completes it and
+// is told to keep its output, again if it reports twice; any other copy is
+// told to discard it.
+func (ms *Master) ReportTask(ctx context.Context, in *rpc.TaskResult) (*rpc.UpdateResult, error) {
+	defer ms.syncJournal()
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
+
+	commit := false
+	if job, reduce, id := ms.findTask(in.TaskUuid); job != nil {
+		rec := journalRecord{Type: journalReport, Job: job.ID, Worker: in.Uuid, Reduce: reduce, Task: id,
+			Result: in.Result, Filenames: in.Filenames, Sizes: in.Sizes, Records: in.Records, Error: in.Error,
+			SampleKeys: in.SampleKeys, Counters: CountersFromRPC(in.Counters), CustomCompare: in.CustomCompare, Time: time.Now()}
+		ms.record(rec)
+		commit = ms.applyReport(job, rec)
+		job.advancePhase()
+	}
+	return &rpc.UpdateResult{Result: commit}, nil
+}
+
+// ReportFetchFailure handles a reducer that could not read an intermediate
+// file. The map task that produced it is executed again, and the reduce
+// attempt is dropped so that it restarts with the new location once the map
+// phase completes again.
+func (ms *Master) ReportFetchFailure(ctx context.Context, in *rpc.FetchFailure) (*rpc.UpdateResult, error) {
+	defer ms.syncJournal()
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
+
+	job, reduce, id := ms.findTask(in.TaskUuid)
+	if job == nil || !reduce {
+		return nil, status.Errorf(codes.NotFound, "reduce task %v not found", in.TaskUuid)
+	}
+	ms.record(journalRecord{Type: journalFetchFailure, Job: job.ID, Worker: in.Uuid, Reduce: true, Task: id,
+		IP: in.Ip, Filenames: []string{in.Filename}})
+	ms.applyFetchFailure(job, id, in.Uuid, in.Ip, in.Filename)
+	return &rpc.UpdateResult{Result: true}, nil
+}
 
-func (ms *Master) waitForEnoughWorker() {
-	log.Trace("[Master] Wait for enough workers")
-	nWorker, totalWorker := ms.getWorkerNum()
-	for nWorker < totalWorker {
-		nWorker, totalWorker = ms.getWorkerNum()
+// SubmitJob queues a job for the registered workers.
+func (ms *Master) SubmitJob(ctx context.Context, in *rpc.JobSpec) (*rpc.JobInfo, error) {
+	id, err := ms.Submit(JobSpec{
+		ID:          in.Id,
+		Files:       in.Files,
+		Plugin:      in.Plugin,
+		App:         in.App,
+		NReduce:     int(in.NReduce),
+		OutputDir:   in.OutputDir,
+		Hosts:       in.Hosts,
+		Compression: in.Compression,
+		Partitioner: in.Partitioner,
+		InputFormat: in.InputFormat,
+		SideInputs:  SideInputs{Params: in.Params, CacheFiles: in.CacheFiles},
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-	log.Trace("[Master] Enough workers!")
+	return &rpc.JobInfo{Id: id}, nil
 }
 
-func (ms *Master) getWorkerNum() (int, int) {
+func (ms *Master) WaitJob(ctx context.Context, in *rpc.JobInfo) (*rpc.JobInfo, error) {
 	ms.mux.Lock()
-	nWorkers := ms.numWorkers
-	tWorkers := ms.totalWorkers
+	job := ms.findJob(in.Id)
 	ms.mux.Unlock()
-	return nWorkers, tWorkers
+	if job == nil {
+		return nil, status.Errorf(codes.NotFound, "job %v not found", in.Id)
+	}
+	select {
+	case <-job.done:
+		return &rpc.JobInfo{Id: job.ID, Done: true, Failure: job.err.toRPC()}, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
 }
 
-func (ms *Master) distributeWork(files []string) {
-	log.Trace("[Master] Start distribute workload")
-	numWorkers := ms.totalWorkers
-	// Initialize MapTasks
-	ms.MapTasks = newMapTasks(numWorkers)
+func (ms *Master) GetJobStatus(ctx context.Context, in *rpc.JobInfo) (*rpc.JobStatus, error) {
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
+
+	var job *Job
+	if in.Id != "" {
+		job = ms.findJob(in.Id)
+	} else {
+		for _, j := range ms.Jobs {
+			job = j
+			if !j.finished() {
+				break
+			}
+		}
+	}
+	if job == nil {
+		return nil, status.Errorf(codes.NotFound, "job %v not found", in.Id)
+	}
+	return job.toRPC(), nil
+}
 
-	// Distribute work
-	for _, file := range files {
-		lineOffsets, err := fileLineOffsets(file)
-		if err != nil {
-			panic(err)
+// expireTasks charges the attempts that ran for too long as failed and
+// re-queues their tasks. It runs on every task request and heartbeat round,
+// so that jobs whose workers all hang still time out.
+func (ms *Master) expireTasks() {
+	for _, job := range ms.runningJobs() {
+		for reduce, id, worker := job.timedOutTask(); id >= 0; reduce, id, worker = job.timedOutTask() {
+			ms.record(journalRecord{Type: journalTimeout, Job: job.ID, Worker: worker, Reduce: reduce, Task: id})
+			ms.applyTimeout(job, reduce, id, worker)
 		}
-		totalLine := len(lineOffsets) - 1
-		baseWorkLoad := totalLine / numWorkers
-
-		from := 0
-		for i := 0; i < numWorkers; i++ {
-			workLoad := baseWorkLoad
-			if i < (totalLine % numWorkers) {
-				workLoad++
-			}
+	}
+}
+
+// applyTimeout drops the attempt of a task that ran too long on workerUUID and
+// charges it like a failed one, so that a task hanging on every worker fails
+// the job.
+func (ms *Master) applyTimeout(job *Job, reduce bool, id int, workerUUID string) {
+	log.Warn(fmt.Sprintf("[Master] %v task %v of job %v timed out on %v", taskKind(reduce), id, job.ID, workerUUID))
+	job.statuses(reduce)[id].fail(workerUUID)
+	ms.taskFailed(job, reduce, id, workerUUID, "timed out")
+}
 
-			// Use byte offsets directly so workers can seek without rescanning.
-			startByte := int(lineOffsets[from])
-			endByte := int(lineOffsets[from+workLoad])
-			ms.MapTasks[i].addFile(file, startByte, endByte)
-			from += workLoad
+// applyFetchFailure re-executes the map task whose output a reduce task could
+// not fetch. The job fails once the same reduce task failed to fetch the
+// output of the same map task MR_FETCH_MAX_FAILURES times, since running them
+// again would not help.
+func (ms *Master) applyFetchFailure(job *Job, reduceID int, workerUUID string, ip string, filename string) {
+	log.Warn(fmt.Sprintf("[Master] Reduce task %v of job %v on %v failed to fetch %v from %v", reduceID, job.ID, workerUUID, filename, ip))
+	if job.finished() {
+		return
+	}
+	for i := range job.MapTasks {
+		task := &job.MapTasks[i]
+		if task.TaskState != TASK_COMPLETED || !task.produced(ip, filename) {
+			continue
+		}
+		pair := [2]int{i, reduceID}
+		job.fetchFailures[pair]++
+		if n := job.fetchFailures[pair]; n >= intFromEnv("MR_FETCH_MAX_FAILURES", 4) {
+			job.fail(&JobError{JobID: job.ID, Kind: taskKind(true), Task: reduceID, Attempts: n,
+				LastError: fmt.Sprintf("cannot fetch the output of Map task %v: %v from %v", i, filename, ip)})
+			return
+		}
+		log.Info(fmt.Sprintf("[Master] Re-execute Map task %v of job %v, its output on %v is lost", i, job.ID, ip))
+		task.setState(TASK_IDLE)
+		task.IMDs = nil
+		if job.phase == PHASE_REDUCE {
+			job.phase = PHASE_MAP
 		}
 	}
-	log.Trace("[Master] End distribute workload")
+	job.ReduceTasks[reduceID].fail(workerUUID)
 }
 
-func (ms *Master) availableWorkers(num int) ([]*WorkerInfo, int) {
-	log.Info("[Master] Finding available workers to execute ", num, "/", len(ms.Workers))
-	var retInfo []*WorkerInfo
-	total := 0
-	broken := 0
-
-LOOP:
-	for {
-		// ms.mux.Lock()
-		broken = 0
-		for i := range ms.Workers {
-			if total >= num {
-				break LOOP
-			}
-			if ms.Workers[i].Health() {
-				retInfo = append(retInfo, &ms.Workers[i])
-				ms.Workers[i].SetState(WORKER_BUSY)
-				total += 1
-			} else if ms.Workers[i].Broken() {
-				broken += 1
+// applyReport records the outcome of an attempt of a map or reduce task, as
+// described by a report record, and tells whether its output is committed.
+func (ms *Master) applyReport(job *Job, rec journalRecord) bool {
+	reduce, id, workerUUID, ok, errMsg := rec.Reduce, rec.Task, rec.Worker, rec.Result, rec.Error
+	filenames, sizes := rec.Filenames, rec.Sizes
+	if ok && job.statuses(reduce)[id].committedBy(workerUUID) {
+		// A report applied before, retried after its reply was lost.
+		return true
+	}
+	if job.finished() {
+		if !ok && job.statuses(reduce)[id].committedBy(workerUUID) {
+			log.Error(fmt.Sprintf("[Master] %v task %v of job %v failed to commit on %v after the job was done: %v", taskKind(reduce), id, job.ID, workerUUID, errMsg))
+		}
+		return false
+	}
+	if !reduce && job.phase == PHASE_SAMPLE {
+		return ms.applySample(job, id, workerUUID, ok, errMsg, rec.SampleKeys, rec.CustomCompare, rec.Time)
+	}
+	if !ok && job.statuses(reduce)[id].reopen(workerUUID) {
+		// The attempt won, but its worker could not commit the output.
+		log.Warn(fmt.Sprintf("[Master] %v task %v of job %v failed to commit on %v: %v", taskKind(reduce), id, job.ID, workerUUID, errMsg))
+		if !reduce {
+			job.MapTasks[id].IMDs = nil
+			if job.phase == PHASE_REDUCE {
+				job.phase = PHASE_MAP
 			}
 		}
-		if broken == len(ms.Workers) {
-			log.Warn("No enough worker now, retrying...", total, num)
-			time.Sleep(200 * time.Millisecond)
-			continue
+		ms.taskFailed(job, reduce, id, workerUUID, errMsg)
+		return false
+	}
+	if !reduce && ok && len(filenames) != job.numReducer {
+		errMsg = fmt.Sprintf("returned %v partitions, expect %v", len(filenames), job.numReducer)
+		ok = false
+	}
+	if !ok && job.statuses(reduce)[id].runningOn(workerUUID) {
+		defer ms.taskFailed(job, reduce, id, workerUUID, errMsg)
+	}
+
+	if reduce {
+		commit := job.ReduceTasks[id].finish(workerUUID, ok, rec.Time)
+		if commit {
+			job.ReduceTasks[id].Records = rec.Records
+			job.ReduceTasks[id].Counters = rec.Counters
+			job.countLocality(true, id, hostOf(ms.serviceDiscovey(workerUUID)))
+			log.Info(fmt.Sprintf("[Master] Reduce task %v of job %v done by %v", id, job.ID, workerUUID))
+		} else if !ok {
+			log.Warn(fmt.Sprintf("[Master] Reduce task %v of job %v failed on %v: %v", id, job.ID, workerUUID, errMsg))
+		}
+		return commit
+	}
+
+	task := &job.MapTasks[id]
+	commit := task.finish(workerUUID, ok, rec.Time)
+	if commit {
+		task.Records = rec.Records
+		task.Counters = rec.Counters
+		task.IMDs = nil
+		for i, f := range filenames {
+			imd := IMDInfo{
+				IP:       ms.serviceDiscovey(workerUUID),
+				FileName: f,
+			}
+			if i < len(sizes) {
+				imd.Size = sizes[i]
+			}
+			task.IMDs = append(task.IMDs, imd)
 		}
-		// ms.mux.Unlock()
+		job.countLocality(false, id, hostOf(ms.serviceDiscovey(workerUUID)))
+		log.Info(fmt.Sprintf("[Master] Map task %v of job %v done by %v", id, job.ID, workerUUID))
+	} else if !ok {
+		log.Warn(fmt.Sprintf("[Master] Map task %v of job %v failed on %v: %v", id, job.ID, workerUUID, errMsg))
 	}
+	return commit
+}
 
-	retWorkers := len(retInfo)
-	return retInfo, retWorkers
+// applySample records the outcome of a sample attempt of a map task.
+func (ms *Master) applySample(job *Job, id int, workerUUID string, ok bool, errMsg string, keys []string, customCompare bool, at time.Time) bool {
+	if ok && customCompare {
+		// Split points are picked and searched in byte order, which would
+		// not match the order the reducers sort their keys in.
+		job.fail(&JobError{JobID: job.ID, Kind: taskKind(false), Task: id, Attempts: 1,
+			LastError: "the range partitioner orders keys byte-wise and cannot be used with a plugin Compare function"})
+		return false
+	}
+	if !ok && job.MapTasks[id].runningOn(workerUUID) {
+		defer ms.taskFailed(job, false, id, workerUUID, errMsg)
+	}
+	commit := job.MapTasks[id].finish(workerUUID, ok, at)
+	if commit {
+		job.samples[id] = keys
+		log.Info(fmt.Sprintf("[Master] Map task %v of job %v sampled %v keys on %v", id, job.ID, len(keys), workerUUID))
+	} else if !ok {
+		log.Warn(fmt.Sprintf("[Master] Sampling map task %v of job %v failed on %v: %v", id, job.ID, workerUUID, errMsg))
+	}
+	return commit
 }
 
-func lineNums(file string) int {
-	f, err := os.Open(file)
-	if err != nil {
-		panic(err)
+// taskFailed charges a failed attempt to the task and to the worker it ran on.
+// The job fails once the task runs out of attempts, or once every worker is
+// blacklisted for it.
+func (ms *Master) taskFailed(job *Job, reduce bool, id int, workerUUID string, errMsg string) {
+	task := job.statuses(reduce)[id]
+	task.Failures++
+	task.LastError = errMsg
+	jobErr := &JobError{JobID: job.ID, Kind: taskKind(reduce), Task: id, Attempts: task.Failures, LastError: errMsg}
+	if task.Failures >= intFromEnv("MR_TASK_MAX_ATTEMPTS", 4) {
+		job.fail(jobErr)
+		return
 	}
 
-	defer f.Close()
+	job.failures[workerUUID]++
+	if !job.blacklisted(workerUUID) {
+		return
+	}
+	log.Warn(fmt.Sprintf("[Master] Blacklist worker %v for job %v after %v failures", workerUUID, job.ID, job.failures[workerUUID]))
+	for i := range ms.Workers {
+		if !ms.Workers[i].Broken() && !job.blacklisted(ms.Workers[i].UUID) {
+			return
+		}
+	}
+	jobErr.LastError = "no usable worker left, last error: " + errMsg
+	job.fail(jobErr)
+}
 
-	scanner := bufio.NewScanner(f)
+func (ms *Master) serviceDiscovey(uuid string) string {
+	var ip string
 
-	num := 0
-	for scanner.Scan() {
-		num++
+	for i := range ms.Workers {
+		if ms.Workers[i].UUID == uuid {
+			ip = ms.Workers[i].getIP()
+		}
 	}
-	return num
+
+	return ip
 }
 
-func fileLineOffsets(file string) ([]int64, error) {
-	f, err := os.Open(file)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	reader := bufio.NewReader(f)
-	var offsets []int64
-	offsets = append(offsets, 0)
-	var cursor int64
-	for {
-		line, err := reader.ReadString('\n')
-		if len(line) > 0 {
-			cursor += int64(len(line))
-			offsets = append(offsets, cursor)
+// Normal Functions
+
+// addWorker adds a registered worker and returns the number of workers.
+func (ms *Master) addWorker(uuid string, ip string) int {
+	ms.Workers = append(ms.Workers, newWorker(uuid, ip))
+	ms.numWorkers++
+	return ms.numWorkers
+}
+
+func (ms *Master) workerIndex(uuid string) int {
+	for i := range ms.Workers {
+		if ms.Workers[i].UUID == uuid {
+			return i
 		}
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, err
+	}
+	return -1
+}
+
+func (ms *Master) findJob(id string) *Job {
+	for _, job := range ms.Jobs {
+		if job.ID == id {
+			return job
 		}
 	}
-	return offsets, nil
+	return nil
 }
 
-func orDone(finish, crashChan <-chan string, numberOfTasks int) <-chan string {
-	count := 0
-	valStream := make(chan string)
-	go func() {
-		defer close(valStream)
-		for {
-			select {
-			case <-finish:
-				count += 1
-				if count == numberOfTasks {
-					return
-				}
-			case crashUUID, ok := <-crashChan:
-				if !ok {
-					if count != numberOfTasks {
-						log.Panic("faild to distribute all Tasks")
-						return
-					}
-				}
+// findTask returns the job, kind and index of the task with the given UUID.
+func (ms *Master) findTask(uuid string) (*Job, bool, int) {
+	for _, job := range ms.Jobs {
+		if reduce, id := job.findTask(uuid); id >= 0 {
+			return job, reduce, id
+		}
+	}
+	return nil, false, -1
+}
 
-				valStream <- crashUUID
+func (ms *Master) allJobsDone() bool {
+	return len(ms.runningJobs()) == 0
+}
 
-			}
-		}
-	}()
-	return valStream
+// runningJobs returns the jobs from the oldest one still running on. Jobs are
+// served in submission order, so the finished ones mostly gather before it
+// and a long-lived master does not scan them for every task request.
+func (ms *Master) runningJobs() []*Job {
+	for ms.firstRunning < len(ms.Jobs) && ms.Jobs[ms.firstRunning].finished() {
+		ms.firstRunning++
+	}
+	return ms.Jobs[ms.firstRunning:]
 }
 
-func (ms *Master) distributeMapTask() {
-	log.Trace("[Master] Start Map task")
-
-	taskStates := sync.Map{}
-
-	finish := make(chan string, 100)
-	defer close(finish)
-	// crashChan := make(chan MapTaskInfo, 100)
-	workerID := 0
-	workers, nWorkers := ms.availableWorkers(ms.totalWorkers)
-	for _, mapTask := range ms.MapTasks {
-		mapTask.setState(TASK_INPROGRESS)
-		go func(task MapTaskInfo, id int) {
-			taskStates.Store(workers[id].UUID, task)
-			done := ms.client.Map(workers[id].IP, task.toRPC())
-			if !done {
-				// task.setState(TASK_IDLE)
-				workers[id].SetState(WORKER_UNKNOWN)
-				ms.crashChan <- workers[id].UUID
-				// crashChan <- task
-			} else {
-				// task.setState(TASK_COMPLETED)
-				workers[id].SetState(WORKER_IDLE)
-				finish <- workers[id].UUID
-			}
-		}(mapTask, workerID)
-		workerID = (workerID + 1) % nWorkers
+// Submit splits the input of a job and queues its tasks. It returns the job ID.
+func (ms *Master) Submit(spec JobSpec) (string, error) {
+	if spec.NReduce <= 0 {
+		spec.NReduce = ms.numReducer
+	}
+	if spec.NReduce <= 0 {
+		return "", fmt.Errorf("job needs at least one reducer")
+	}
+	if spec.Plugin != "" && spec.App != "" {
+		return "", fmt.Errorf("job has both plugin %v and app %v", spec.Plugin, spec.App)
+	}
+	if spec.Compression == "" {
+		spec.Compression = os.Getenv("MR_COMPRESSION")
+	}
+	if !compressions[spec.Compression] {
+		return "", fmt.Errorf("unknown compression %q", spec.Compression)
 	}
+	if spec.Partitioner == "" {
+		spec.Partitioner = os.Getenv("MR_PARTITIONER")
+	}
+	if !partitioners[spec.Partitioner] {
+		return "", fmt.Errorf("unknown partitioner %q", spec.Partitioner)
+	}
+	if spec.InputFormat == "" {
+		spec.InputFormat = os.Getenv("MR_INPUT_FORMAT")
+	}
+	if !inputFormats[spec.InputFormat] {
+		return "", fmt.Errorf("unknown input format %q", spec.InputFormat)
+	}
+	cacheFiles, err := cacheFileInfo(spec.CacheFiles)
+	if err != nil {
+		return "", err
+	}
+	job := newJob(spec)
+	job.cacheFiles = cacheFiles
 
-	for crashUUID := range orDone(finish, ms.crashChan, len(ms.MapTasks)) {
-		workers, _ := ms.availableWorkers(1)
-		log.Info("[Master] Re-execute Map Task from ", crashUUID, " To ", workers[0].UUID)
-		if crashUUID == workers[0].UUID {
-			log.Warn("Re-execute assigned same worker, retrying...")
-			ms.crashChan <- crashUUID
-			continue
+	log.Trace("[Master] Start distribute workload")
+	for _, file := range spec.Files {
+		splits, err := splitFile(file, ms.split, spec.InputFormat)
+		if err != nil {
+			return "", err
 		}
-		reExecuteTask, ok := taskStates.Load(crashUUID)
-		if !ok {
-			log.Warn("Load task states from crashUUID fail")
-			continue
+		for _, split := range splits {
+			task := newMapTask()
+			task.addFile(split.FileName, split.From, split.To)
+			task.Files[0].Host = spec.Hosts[file]
+			job.MapTasks = append(job.MapTasks, task)
 		}
-		go func(task MapTaskInfo, id int) {
-			// taskStates.Delete(crashUUID)
-			taskStates.Store(workers[id].UUID, task)
-			done := ms.client.Map(workers[id].IP, task.toRPC())
-			if !done {
-				task.setState(TASK_IDLE)
-				workers[id].SetState(WORKER_UNKNOWN)
-				ms.crashChan <- workers[id].UUID
-			} else {
-				task.setState(TASK_COMPLETED)
-				workers[id].SetState(WORKER_IDLE)
-				finish <- workers[id].UUID
-			}
-		}(reExecuteTask.(MapTaskInfo), 0)
 	}
+	log.Info(fmt.Sprintf("[Master] Split %v input files of job %v into %v map tasks", len(spec.Files), job.ID, len(job.MapTasks)))
 
-	log.Trace("[Master] End Map task")
+	defer ms.syncJournal()
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
+	if ms.findJob(job.ID) != nil {
+		return "", fmt.Errorf("job %v already exists", job.ID)
+	}
+	ms.recordJob(job)
+	ms.Jobs = append(ms.Jobs, job)
+	job.phase = job.firstPhase()
+	job.advancePhase()
+	log.Trace("[Master] End distribute workload")
+	return job.ID, nil
 }
 
-func (ms *Master) distributeReduceTask() {
-	log.Trace("[Master] Start Reduce task")
-
-	taskStates := sync.Map{}
-
-	finish := make(chan string, 100)
-	defer close(finish)
-	workerID := 0
-	workers, nWorkers := ms.availableWorkers(ms.numReducer)
-	for _, reduceTask := range ms.ReduceTasks {
-		reduceTask.SetState(TASK_INPROGRESS)
-		go func(task ReduceTaskInfo, id int) {
-			taskStates.Store(workers[id].UUID, task)
-			workers[id].SetState(WORKER_BUSY)
-			done := ms.client.Reduce(workers[id].IP, task.toRPC())
-			if !done {
-				task.SetState(TASK_IDLE)
-				workers[id].SetState(WORKER_UNKNOWN)
-				ms.crashChan <- workers[id].UUID
-			} else {
-				task.SetState(TASK_COMPLETED)
-				workers[id].SetState(WORKER_IDLE)
-				finish <- workers[id].UUID
-			}
-		}(reduceTask, workerID)
-		workerID = (workerID + 1) % nWorkers
-	}
-
-	for crashUUID := range orDone(finish, ms.crashChan, len(ms.ReduceTasks)) {
-		workers, _ = ms.availableWorkers(1)
-
-		log.Info("[Master] Re-execute Map Task from ", crashUUID, "To ", workers[0].UUID)
-		reExecuteTask, _ := taskStates.Load(crashUUID)
-		go func(task ReduceTaskInfo, id int) {
-			taskStates.Delete(crashUUID)
-			taskStates.Store(workers[id].UUID, task)
-			done := ms.client.Reduce(workers[id].IP, task.toRPC())
-			if !done {
-				task.SetState(TASK_IDLE)
-				workers[id].SetState(WORKER_UNKNOWN)
-				ms.crashChan <- workers[id].UUID
-			} else {
-				task.SetState(TASK_COMPLETED)
-				workers[id].SetState(WORKER_IDLE)
-				finish <- workers[id].UUID
-			}
-		}(reExecuteTask.(ReduceTaskInfo), 0)
+// Wait blocks until the job is done. It returns a *JobError if the job failed.
+func (ms *Master) Wait(id string) error {
+	ms.mux.Lock()
+	job := ms.findJob(id)
+	ms.mux.Unlock()
+	if job == nil {
+		return fmt.Errorf("job %v not found", id)
+	}
+	<-job.done
+	if job.err != nil {
+		return job.err
 	}
+	return nil
+}
+
+func (ms *Master) recordJob(job *Job) {
+	rec := journalRecord{Type: journalJob, Job: job.ID, Plugin: job.Plugin, App: job.App, OutputDir: job.OutputDir,
+		Compression: job.Compression, Partitioner: job.Partitioner, InputFormat: job.InputFormat,
+		Params: job.Params, CacheFiles: job.CacheFiles}
+	for _, task := range job.MapTasks {
+		rec.MapFiles = append(rec.MapFiles, task.Files)
+		rec.MapUUIDs = append(rec.MapUUIDs, task.UUID)
+	}
+	for _, task := range job.ReduceTasks {
+		rec.ReduceUUIDs = append(rec.ReduceUUIDs, task.UUID)
+	}
+	ms.record(rec)
+}
 
-	log.Trace("[Master] End Reduce task")
+// waitForJobs waits for every job and returns the first failure.
+func (ms *Master) waitForJobs() error {
+	ms.mux.Lock()
+	jobs := append([]*Job(nil), ms.Jobs...)
+	ms.mux.Unlock()
+	var ret error
+	for _, job := range jobs {
+		<-job.done
+		if job.err != nil && ret == nil {
+			ret = job.err
+		}
+	}
+	return ret
 }
 
 func (ms *Master) endWorkers() {
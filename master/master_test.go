@@ -2,11 +2,18 @@ package master
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/emptyOVO/mrkit-go/master/mocks"
 	"github.com/emptyOVO/mrkit-go/rpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestWorkerRegister(t *testing.T) {
@@ -36,8 +43,11 @@ func TestDistributeWork(t *testing.T) {
 		t.Error(err)
 	}
 
-	master.distributeWork(fileNames)
-	for counter, task := range master.MapTasks {
+	id, err := master.Submit(JobSpec{Files: fileNames})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for counter, task := range master.findJob(id).MapTasks {
 		if counter == 0 {
 			for _, fileInfo := range task.Files {
 				if fileInfo.From != 0 && fileInfo.To != 2 {
@@ -48,100 +58,1023 @@ func TestDistributeWork(t *testing.T) {
 	}
 }
 
-func TestDistributeMapTask(t *testing.T) {
+func TestSplitPolicy(t *testing.T) {
+	fileNames, err := createTestFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	splits, err := splitFile(fileNames[0], SplitPolicy{TargetBytes: DefaultSplitBytes}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(splits) != 1 || splits[0].From != 0 || splits[0].To != 52 {
+		t.Error("small file should be a single split", splits)
+	}
+
+	splits, _ = splitFile(fileNames[0], SplitPolicy{TargetLines: 1}, "")
+	if len(splits) != 3 || splits[1].From != 31 || splits[1].To != 43 || splits[2].To != 52 {
+		t.Error("file should be split per line", splits)
+	}
+
+	splits, _ = splitFile(fileNames[0], SplitPolicy{TargetBytes: 40}, "")
+	if len(splits) != 2 || splits[0].To != 43 {
+		t.Error("split should end on the first line boundary past the target", splits)
+	}
+
+	csvFile := filepath.Join(t.TempDir(), "in.csv")
+	os.WriteFile(csvFile, []byte("a,\"x\ny\"\nb,c\n"), 0644)
+	splits, _ = splitFile(csvFile, SplitPolicy{TargetLines: 1}, "csv")
+	if len(splits) != 2 || splits[0].To != 8 {
+		t.Error("csv should be split on record boundaries", splits)
+	}
+	splits, _ = splitFile(csvFile, SplitPolicy{TargetLines: 1}, "gzip")
+	if len(splits) != 1 {
+		t.Error("gzip files should not be split", splits)
+	}
+	if _, err := NewMaster(1, 1).(*Master).SubmitJob(context.Background(), &rpc.JobSpec{Files: fileNames, NReduce: 1, InputFormat: "xml"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown input format should be rejected, got %v", err)
+	}
+
+	master := NewMaster(1, 1).(*Master)
+	master.split = SplitPolicy{TargetLines: 2}
+	master.Submit(JobSpec{Files: fileNames})
+	if len(master.Jobs[0].MapTasks) != 4 {
+		t.Error("map task count should follow the split policy, got", len(master.Jobs[0].MapTasks))
+	}
+}
+
+func TestRequestTaskMap(t *testing.T) {
 	master := NewMaster(2, 1).(*Master)
 	master.client = mocks.WorkerClient{}
-
-	workerInfo := WorkerInfo{UUID: "uuid", IP: "ip", WorkerState: WORKER_IDLE}
-	workerInfo1 := WorkerInfo{UUID: "uuid1", IP: "ip1", WorkerState: WORKER_IDLE}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
 
 	mapTaskFile := "test"
 
-	master.MapTasks = []MapTaskInfo{{}}
-	master.MapTasks[0].addFile(mapTaskFile, 0, 1)
-	master.Workers = append(master.Workers, workerInfo, workerInfo1)
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	job.MapTasks[0].addFile(mapTaskFile, 0, 1)
 
-	mocks.Result = true
-	master.distributeMapTask()
-	req, _ := mocks.Request.(*rpc.MapInfo)
+	task, err := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Type != rpc.Task_MAP || task.Uuid != job.MapTasks[0].UUID {
+		t.Fatal("expect the map task to be assigned")
+	}
+	req := task.Map
 	if req.Files[0].FileName != mapTaskFile || req.Files[0].From != 0 || req.Files[0].To != 1 {
 		t.Error("request to worker is not correct")
 	}
+
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid1", Ip: "ip1"})
+	if task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid1"}); task.Type != rpc.Task_WAIT {
+		t.Error("running task should not be assigned twice")
+	}
+	// The worker asks again, as if the first reply was lost.
+	if task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"}); task.Uuid != job.MapTasks[0].UUID {
+		t.Error("expect the task running on the worker again")
+	}
+	if job.MapTasks[0].Attempts != 1 {
+		t.Error("a repeated request should not launch another attempt, got", job.MapTasks[0].Attempts)
+	}
 }
 
-func TestDistributeMapTaskFailsTolerant(t *testing.T) {
+func TestRequestTaskUnregistered(t *testing.T) {
 	master := NewMaster(2, 1).(*Master)
 	master.client = mocks.WorkerClient{}
 
-	workerInfo := WorkerInfo{UUID: "uuid", IP: "ip", WorkerState: WORKER_IDLE}
-	workerInfo1 := WorkerInfo{UUID: "uuid1", IP: "ip1", WorkerState: WORKER_IDLE}
+	if _, err := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"}); err == nil {
+		t.Error("unregistered worker should not get a task")
+	}
+}
 
-	mapTaskFile := "test"
+func TestRequestTaskReportsEndedJobs(t *testing.T) {
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
 
-	master.MapTasks = []MapTaskInfo{{}}
-	master.MapTasks[0].addFile(mapTaskFile, 0, 1)
-	master.Workers = append(master.Workers, workerInfo, workerInfo1)
+	done := newTestJob(master, []MapTaskInfo{newMapTask()})
+	done.phase = PHASE_DONE
+	running := newTestJob(master, []MapTaskInfo{newMapTask()})
 
-	mocks.Result = false
-	master.distributeMapTask()
-	req, _ := mocks.Request.(*rpc.MapInfo)
-	if req.Files[0].FileName != mapTaskFile || req.Files[0].From != 0 || req.Files[0].To != 1 {
-		t.Error("request to worker is not correct")
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Jobs: []string{done.ID, running.ID, "forgotten"}})
+	if fmt.Sprint(task.EndedJobs) != fmt.Sprint([]string{done.ID, "forgotten"}) {
+		t.Errorf("expect the done and unknown jobs to be reported ended, got %v", task.EndedJobs)
+	}
+}
+
+func TestUpdateIMDInfoChecksPartitions(t *testing.T) {
+	master := NewMaster(1, 2).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+
+	if _, err := master.UpdateIMDInfo(context.Background(), &rpc.IMDInfo{Uuid: "uuid", JobId: job.ID, Filenames: []string{"a", "b", "c"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expect InvalidArgument for more files than reducers, got %v", err)
+	}
+}
+
+func TestRequestTaskSkipsFinishedJobs(t *testing.T) {
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+
+	for i := 0; i < 3; i++ {
+		newTestJob(master, []MapTaskInfo{newMapTask()}).phase = PHASE_DONE
+	}
+	running := newTestJob(master, []MapTaskInfo{newMapTask()})
+	if task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"}); task.Type != rpc.Task_MAP || task.Map.JobId != running.ID {
+		t.Fatalf("expect the map task of the running job, got %v", task)
+	}
+	if master.firstRunning != 3 {
+		t.Errorf("finished jobs should be skipped from now on, first running job is %v", master.firstRunning)
 	}
 }
 
-func TestDistributeReduceTask(t *testing.T) {
+func TestReportFromDroppedAttemptIsRejected(t *testing.T) {
+	t.Setenv("MR_LOCALITY", "false")
 	master := NewMaster(2, 1).(*Master)
 	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "old", Ip: "ip"})
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "new", Ip: "ip1"})
 
-	workerInfo := WorkerInfo{UUID: "uuid", IP: "ip", WorkerState: WORKER_IDLE}
-	workerInfo1 := WorkerInfo{UUID: "uuid1", IP: "ip1", WorkerState: WORKER_IDLE}
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "old"})
+	job.MapTasks[0].StartTime = time.Now().Add(-time.Hour)
+	master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "new"})
+
+	res, _ := master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "old", TaskUuid: task.Uuid, Result: true, Filenames: []string{"imd"}})
+	if res.Result || job.MapTasks[0].TaskState != TASK_INPROGRESS || !job.MapTasks[0].runningOn("new") {
+		t.Error("a timed-out attempt should not complete the task")
+	}
+}
+
+func TestReportTaskFailsTolerant(t *testing.T) {
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid1", Ip: "ip1"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	job.MapTasks[0].addFile("test", 0, 1)
 
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: false})
+
+	retry, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid1"})
+	if retry.Type != rpc.Task_MAP || retry.Uuid != task.Uuid {
+		t.Fatal("failed map task should be re-assigned")
+	}
+	if job.MapTasks[0].Attempts != 2 {
+		t.Error("attempts should be counted")
+	}
+	if task.Attempt != 1 || retry.Attempt != 2 {
+		t.Errorf("expected attempts 1 and 2, got %v and %v", task.Attempt, retry.Attempt)
+	}
+}
+
+func TestReduceTaskAfterMap(t *testing.T) {
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+
+	master.exitWhenDone = true
 	IMDFileName := "testReduceTasks"
 
-	master.ReduceTasks = []ReduceTaskInfo{{IMDs: []IMDInfo{
-		{FileName: IMDFileName},
-	}}}
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	job.MapTasks[0].addFile("test", 0, 1)
 
-	master.Workers = append(master.Workers, workerInfo, workerInfo1)
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	master.ReportTask(context.Background(), &rpc.TaskResult{
+		Uuid:      "uuid",
+		TaskUuid:  task.Uuid,
+		Result:    true,
+		Filenames: []string{IMDFileName},
+	})
 
-	mocks.Result = true
-	master.distributeReduceTask()
-	req, _ := mocks.Request.(*rpc.ReduceInfo)
-	if req.Files[0].Filename != IMDFileName {
+	task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if task.Type != rpc.Task_REDUCE {
+		t.Fatal("expect the reduce task to be assigned")
+	}
+	req := task.Reduce
+	if req.Files[0].Filename != IMDFileName || req.Files[0].Ip != "ip" {
 		t.Error("request to worker is not correct")
 	}
+
+	master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: true})
+	task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if task.Type != rpc.Task_EXIT {
+		t.Error("worker should exit after the job is done")
+	}
+	master.waitForJobs()
+}
+
+func TestMultipleJobs(t *testing.T) {
+	master := NewMaster(0, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+
+	files, err := createTestFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, f := range files {
+			os.Remove(f)
+		}
+	}()
+	if _, err := master.Submit(JobSpec{ID: "first", Files: files[:1], Plugin: "first.so", NReduce: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := master.Submit(JobSpec{ID: "second", Files: files[1:], Plugin: "second.so", Compression: "gzip"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := master.Submit(JobSpec{ID: "first"}); err == nil {
+		t.Error("job IDs should be unique")
+	}
+	if _, err := master.Submit(JobSpec{ID: "third", Compression: "zstd"}); err == nil {
+		t.Error("unknown compressions should be rejected")
+	}
+	if _, err := master.Submit(JobSpec{ID: "third", Plugin: "third.so", App: "third"}); err == nil {
+		t.Error("a job should not have both a plugin and an app")
+	}
+
+	codec := map[string]string{"second": "gzip"}
+	run := func(jobID string, taskType rpc.Task_Type) {
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+		if task.Type != taskType {
+			t.Fatalf("expect %v task of job %v, got %v", taskType, jobID, task)
+		}
+		result := &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: true}
+		if taskType == rpc.Task_MAP {
+			if task.Map.JobId != jobID || task.Plugin != jobID+".so" || task.Map.Compression != codec[jobID] {
+				t.Errorf("map task should belong to job %v, got %v", jobID, task)
+			}
+			for i := int64(0); i < task.Map.NReduce; i++ {
+				result.Filenames = append(result.Filenames, fmt.Sprintf("imd-%v", i))
+			}
+		} else if task.Reduce.JobId != jobID || task.Reduce.Compression != codec[jobID] {
+			t.Errorf("reduce task should belong to job %v, got %v", jobID, task)
+		}
+		master.ReportTask(context.Background(), result)
+	}
+	// Jobs are served in submission order.
+	run("first", rpc.Task_MAP)
+	run("first", rpc.Task_REDUCE)
+	run("first", rpc.Task_REDUCE)
+	if err := master.Wait("first"); err != nil {
+		t.Fatal(err)
+	}
+	run("second", rpc.Task_MAP)
+	run("second", rpc.Task_REDUCE)
+	master.Wait("second")
+
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if task.Type != rpc.Task_WAIT {
+		t.Error("workers should wait for the next job")
+	}
 }
 
-func TestDistributeReduceTaskFailsTolerant(t *testing.T) {
+func TestFetchFailureReexecutesMap(t *testing.T) {
+	t.Setenv("MR_LOCALITY", "false")
 	master := NewMaster(2, 1).(*Master)
 	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "mapper", Ip: "mapper-ip"})
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "reducer", Ip: "reducer-ip"})
 
-	workerInfo := WorkerInfo{UUID: "uuid", IP: "ip", WorkerState: WORKER_IDLE}
-	workerInfo1 := WorkerInfo{UUID: "uuid1", IP: "ip1", WorkerState: WORKER_IDLE}
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
 
-	IMDFileName := "testReduceTasks"
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "mapper"})
+	master.ReportTask(context.Background(), &rpc.TaskResult{
+		Uuid:      "mapper",
+		TaskUuid:  task.Uuid,
+		Result:    true,
+		Filenames: []string{"imd"},
+	})
 
-	master.ReduceTasks = []ReduceTaskInfo{{IMDs: []IMDInfo{
-		{FileName: IMDFileName},
-	}}}
+	task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "reducer"})
+	master.ReportFetchFailure(context.Background(), &rpc.FetchFailure{
+		Uuid:     "reducer",
+		TaskUuid: task.Uuid,
+		Ip:       "mapper-ip",
+		Filename: "imd",
+	})
+	if job.phase != PHASE_MAP || job.MapTasks[0].TaskState != TASK_IDLE {
+		t.Fatal("map task with lost output should be re-queued")
+	}
+	if job.ReduceTasks[0].TaskState != TASK_IDLE {
+		t.Error("reduce attempt should be dropped")
+	}
 
-	master.Workers = append(master.Workers, workerInfo, workerInfo1)
+	task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "reducer"})
+	if task.Type != rpc.Task_MAP {
+		t.Fatal("expect the map task to be re-executed")
+	}
+	master.ReportTask(context.Background(), &rpc.TaskResult{
+		Uuid:      "reducer",
+		TaskUuid:  task.Uuid,
+		Result:    true,
+		Filenames: []string{"imd2"},
+	})
+	task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "reducer"})
+	if task.Type != rpc.Task_REDUCE || task.Reduce.Files[0].Ip != "reducer-ip" || task.Reduce.Files[0].Filename != "imd2" {
+		t.Errorf("reduce task should read the new output, got %v", task)
+	}
+}
 
-	mocks.Result = false
-	master.distributeReduceTask()
-	req, _ := mocks.Request.(*rpc.ReduceInfo)
-	if req.Files[0].Filename != IMDFileName {
-		t.Error("request to worker is not correct")
+func TestRepeatedFetchFailureFailsJob(t *testing.T) {
+	t.Setenv("MR_LOCALITY", "false")
+	t.Setenv("MR_FETCH_MAX_FAILURES", "3")
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "mapper", Ip: "mapper-ip"})
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "reducer", Ip: "reducer-ip"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	// The reducer never gets the output of the mapper, however often the map
+	// task runs again.
+	for i := 0; i < 3; i++ {
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "mapper"})
+		if task.Type != rpc.Task_MAP {
+			t.Fatalf("round %v: expect the map task, got %v", i, task.Type)
+		}
+		master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "mapper", TaskUuid: task.Uuid, Result: true, Filenames: []string{"imd"}})
+		task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "reducer"})
+		if task.Type != rpc.Task_REDUCE {
+			t.Fatalf("round %v: expect the reduce task, got %v", i, task.Type)
+		}
+		master.ReportFetchFailure(context.Background(), &rpc.FetchFailure{Uuid: "reducer", TaskUuid: task.Uuid, Ip: "mapper-ip", Filename: "imd"})
+	}
+
+	var jobErr *JobError
+	if err := master.Wait(job.ID); !errors.As(err, &jobErr) {
+		t.Fatalf("expect a JobError, got %v", err)
+	}
+	if jobErr.Kind != "Reduce" || jobErr.Task != 0 || jobErr.Attempts != 3 {
+		t.Errorf("unexpected job error %+v", jobErr)
+	}
+	if task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "mapper"}); task.Type != rpc.Task_WAIT {
+		t.Errorf("a failed job should hand out no more tasks, got %v", task.Type)
+	}
+}
+
+func TestCommitFailureReopensTask(t *testing.T) {
+	t.Setenv("MR_LOCALITY", "false")
+	master := NewMaster(1, 2).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	report := func(task *rpc.Task, ok bool) bool {
+		result := &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: ok}
+		if ok && task.Type == rpc.Task_MAP {
+			result.Filenames = []string{"imd-0", "imd-1"}
+		} else if !ok {
+			result.Error = "commit output: read-only file system"
+		}
+		res, _ := master.ReportTask(context.Background(), result)
+		return res.Result
+	}
+
+	mapTask, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	report(mapTask, true)
+	reduceTask, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if !report(reduceTask, true) {
+		t.Fatal("the only attempt should commit")
+	}
+	// The worker failed to rename its output after the master said commit.
+	report(reduceTask, false)
+	if job.ReduceTasks[0].TaskState != TASK_IDLE || job.ReduceTasks[0].Failures != 1 {
+		t.Fatalf("reduce task should be queued again and charged, got %+v", job.ReduceTasks[0].TaskStatus)
+	}
+
+	// A map task failing to commit its named outputs runs again, before any
+	// more reduce task.
+	report(mapTask, false)
+	if job.phase != PHASE_MAP || job.MapTasks[0].TaskState != TASK_IDLE {
+		t.Fatalf("map task should be queued again, phase %v", job.phase)
+	}
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if task.Type != rpc.Task_MAP {
+		t.Fatalf("expect the map task again, got %v", task.Type)
+	}
+	report(task, true)
+	for i := 0; i < 2; i++ {
+		task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+		if task.Type != rpc.Task_REDUCE {
+			t.Fatalf("expect a reduce task, got %v", task.Type)
+		}
+		report(task, true)
+	}
+	if err := master.Wait(job.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetryBudgetFailsJob(t *testing.T) {
+	t.Setenv("MR_TASK_MAX_ATTEMPTS", "3")
+	t.Setenv("MR_WORKER_MAX_FAILURES", "1")
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "bad"})
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "good"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	fail := func(worker string) {
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: worker})
+		if task.Type != rpc.Task_MAP {
+			t.Fatalf("expect a map task for %v, got %v", worker, task.Type)
+		}
+		master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: worker, TaskUuid: task.Uuid, Result: false, Error: "boom"})
+	}
+
+	fail("bad")
+	fail("bad")
+	if task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "bad"}); task.Type != rpc.Task_WAIT {
+		t.Error("blacklisted worker should get no more tasks of the job")
+	}
+	fail("good")
+
+	var jobErr *JobError
+	if err := master.Wait(job.ID); !errors.As(err, &jobErr) {
+		t.Fatalf("expect a JobError, got %v", err)
+	}
+	if jobErr.Kind != "Map" || jobErr.Task != 0 || jobErr.Attempts != 3 || jobErr.LastError != "boom" {
+		t.Errorf("unexpected job error %+v", jobErr)
+	}
+}
+
+func TestTimedOutAttemptsFailJob(t *testing.T) {
+	t.Setenv("MR_TASK_MAX_ATTEMPTS", "2")
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	for i := 0; i < 2; i++ {
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+		if task.Type != rpc.Task_MAP {
+			t.Fatalf("round %v: expect the map task, got %v", i, task.Type)
+		}
+		job.MapTasks[0].StartTime = time.Now().Add(-time.Hour)
+	}
+	master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	var jobErr *JobError
+	if err := master.Wait(job.ID); !errors.As(err, &jobErr) {
+		t.Fatalf("expect a JobError, got %v", err)
+	}
+	if jobErr.Kind != "Map" || jobErr.Attempts != 2 || jobErr.LastError != "timed out" {
+		t.Errorf("unexpected job error %+v", jobErr)
+	}
+}
+
+func TestHealthCheckExpiresHungAttempts(t *testing.T) {
+	t.Setenv("MR_TASK_MAX_ATTEMPTS", "2")
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "primary", Ip: "ip"})
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "backup", Ip: "ip1"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	task := &job.MapTasks[0]
+	task.assign("primary")
+	task.assignBackup("backup")
+	task.BackupStart = time.Now().Add(-time.Hour)
+
+	// No worker asks for a task, the heartbeats alone find the hung backup.
+	master.checkWorkersHealth()
+	if task.BackupUUID != "" || !task.runningOn("primary") || task.Failures != 1 {
+		t.Fatalf("the hung backup should be dropped and charged, got %+v", task.TaskStatus)
+	}
+	task.StartTime = time.Now().Add(-time.Hour)
+	master.checkWorkersHealth()
+
+	var jobErr *JobError
+	if err := master.Wait(job.ID); !errors.As(err, &jobErr) || jobErr.LastError != "timed out" {
+		t.Fatalf("expect the job to fail on the timeouts, got %v", err)
+	}
+}
+
+func TestLostWorkerAttemptsFailJob(t *testing.T) {
+	t.Setenv("MR_TASK_MAX_ATTEMPTS", "2")
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+	defer func() { mocks.State = WORKER_IDLE }()
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	for i := 0; i < 2; i++ {
+		mocks.State = WORKER_IDLE
+		master.checkWorkersHealth()
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+		if task.Type != rpc.Task_MAP {
+			t.Fatalf("round %v: expect the map task, got %v", i, task.Type)
+		}
+		mocks.State = WORKER_UNKNOWN
+		for j := 0; j < 3; j++ {
+			master.checkWorkersHealth()
+		}
+	}
+
+	var jobErr *JobError
+	if err := master.Wait(job.ID); !errors.As(err, &jobErr) {
+		t.Fatalf("expect a JobError, got %v", err)
+	}
+	if jobErr.Kind != "Map" || jobErr.Attempts != 2 || jobErr.LastError != "worker lost" {
+		t.Errorf("unexpected job error %+v", jobErr)
+	}
+}
+
+func TestBlacklistAfterMoreThanMaxFailures(t *testing.T) {
+	t.Setenv("MR_TASK_MAX_ATTEMPTS", "10")
+	t.Setenv("MR_WORKER_MAX_FAILURES", "2")
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	newTestJob(master, []MapTaskInfo{newMapTask()})
+	for i := 0; i < 3; i++ {
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+		if task.Type != rpc.Task_MAP {
+			t.Fatalf("after %v failures: expect the map task, got %v", i, task.Type)
+		}
+		master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: false, Error: "boom"})
+	}
+	if task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"}); task.Type != rpc.Task_WAIT {
+		t.Errorf("a worker with more than 2 failures should be blacklisted, got %v", task.Type)
+	}
+}
+
+func TestLocalityPlacement(t *testing.T) {
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "a", Ip: "host-a:1"})
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "b", Ip: "host-b:1"})
+
+	tasks := []MapTaskInfo{newMapTask(), newMapTask()}
+	tasks[0].Files = []FileInfo{{FileName: "on-a", To: 10, Host: "host-a"}}
+	tasks[1].Files = []FileInfo{{FileName: "on-b", To: 10, Host: "host-b"}}
+	job := newTestJob(master, tasks)
+
+	taskB, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "b"})
+	if taskB.Type != rpc.Task_MAP || taskB.Map.Files[0].FileName != "on-b" {
+		t.Fatalf("worker b should get its local split, got %v", taskB)
+	}
+	taskA, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "a"})
+	if taskA.Type != rpc.Task_MAP || taskA.Map.Files[0].FileName != "on-a" {
+		t.Fatalf("worker a should get its local split, got %v", taskA)
+	}
+	master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "a", TaskUuid: taskA.Uuid, Result: true, Filenames: []string{"imd-a"}, Sizes: []int64{100}})
+	master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "b", TaskUuid: taskB.Uuid, Result: true, Filenames: []string{"imd-b"}, Sizes: []int64{10}})
+
+	// Most of the partition lives on host-a, whose worker is idle.
+	if task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "b"}); task.Type != rpc.Task_WAIT {
+		t.Fatalf("reduce task should be left for host-a, got %v", task.Type)
+	}
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "a"})
+	if task.Type != rpc.Task_REDUCE {
+		t.Fatalf("expect the reduce task on host-a, got %v", task.Type)
+	}
+	master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "a", TaskUuid: task.Uuid, Result: true})
+
+	want := Locality{MapLocal: 2, ReduceLocal: 1, ShuffleBytes: 110, LocalShuffleBytes: 100}
+	if job.Locality != want {
+		t.Errorf("locality %+v, want %+v", job.Locality, want)
+	}
+}
+
+func TestGetJobStatus(t *testing.T) {
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	tasks := []MapTaskInfo{newMapTask(), newMapTask()}
+	tasks[0].addFile("a", 0, 10)
+	tasks[1].addFile("b", 0, 20)
+	job := newTestJob(master, tasks)
+
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: true, Filenames: []string{"imd"}, Records: 7})
+	master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	st, err := master.GetJobStatus(context.Background(), &rpc.JobInfo{})
+	if err != nil || st.Id != job.ID || st.Phase != "map" || len(st.Tasks) != 3 {
+		t.Fatalf("unexpected status %v, err %v", st, err)
+	}
+	want := []struct {
+		kind, state string
+		records     int64
+		bytes       int64
+	}{{"Map", "completed", 7, 10}, {"Map", "in_progress", 0, 20}, {"Reduce", "idle", 0, 0}}
+	for i, w := range want {
+		got := st.Tasks[i]
+		if got.Kind != w.kind || got.State != w.state || got.Records != w.records || got.Bytes != w.bytes {
+			t.Errorf("task %v: got %v, want %+v", i, got, w)
+		}
+	}
+	if st.Tasks[1].Worker != "uuid" || st.Tasks[1].Attempts != 1 {
+		t.Errorf("running task should show its worker and attempt, got %v", st.Tasks[1])
+	}
+}
+
+func TestRangePartitionSampling(t *testing.T) {
+	master := NewMaster(1, 3).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	job := newJob(JobSpec{NReduce: 3, Partitioner: "range"})
+	job.MapTasks = []MapTaskInfo{newMapTask(), newMapTask()}
+	job.phase = job.firstPhase()
+	master.Jobs = append(master.Jobs, job)
+
+	samples := [][]string{{"f", "b", "d"}, {"a", "e", "c"}}
+	for i := range samples {
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+		if task.Type != rpc.Task_MAP || task.Map.SampleKeys == 0 {
+			t.Fatalf("expect a sample task, got %v", task)
+		}
+		master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: true, SampleKeys: samples[i]})
+	}
+
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if task.Type != rpc.Task_MAP || task.Map.SampleKeys != 0 || task.Map.Partitioner != "range" {
+		t.Fatalf("expect a map task after sampling, got %v", task)
+	}
+	if want := []string{"c", "e"}; fmt.Sprint(task.Map.SplitPoints) != fmt.Sprint(want) {
+		t.Errorf("expect split points %v, got %v", want, task.Map.SplitPoints)
+	}
+
+	// Byte-wise split points do not fit a plugin ordering keys otherwise.
+	job = newJob(JobSpec{NReduce: 3, Partitioner: "range"})
+	job.MapTasks = []MapTaskInfo{newMapTask(), newMapTask()}
+	job.phase = job.firstPhase()
+	master.Jobs = append(master.Jobs[:0], job)
+	task, _ = master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: true, SampleKeys: samples[0], CustomCompare: true})
+	if err := master.Wait(job.ID); err == nil || !strings.Contains(err.Error(), "Compare") {
+		t.Errorf("a range job of a plugin with Compare should fail after its first sample, got %v", err)
+	}
+}
+
+func TestSamplingFailuresCountAgainstRetryBudget(t *testing.T) {
+	t.Setenv("MR_TASK_MAX_ATTEMPTS", "2")
+	master := NewMaster(1, 2).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	job := newJob(JobSpec{NReduce: 2, Partitioner: "range"})
+	job.MapTasks = []MapTaskInfo{newMapTask()}
+	job.phase = job.firstPhase()
+	master.Jobs = append(master.Jobs, job)
+
+	report := func(result bool) {
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+		master.ReportTask(context.Background(), &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: result,
+			SampleKeys: []string{"a", "b"}, Error: "boom"})
+	}
+	report(false)
+	report(true)
+	if job.phase != PHASE_MAP {
+		t.Fatalf("expect the map phase after sampling, got %v", job.phase)
+	}
+	report(false)
+
+	var jobErr *JobError
+	if err := master.Wait(job.ID); !errors.As(err, &jobErr) || jobErr.Attempts != 2 {
+		t.Fatalf("a failure while sampling should count against the budget, got %v", err)
+	}
+}
+
+func TestHeartbeatMissRequeuesTasks(t *testing.T) {
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask(), newMapTask()})
+
+	done, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	master.ReportTask(context.Background(), &rpc.TaskResult{
+		Uuid:      "uuid",
+		TaskUuid:  done.Uuid,
+		Result:    true,
+		Filenames: []string{"imd"},
+	})
+	master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	mocks.State = WORKER_UNKNOWN
+	defer func() { mocks.State = WORKER_IDLE }()
+	master.checkWorkersHealth()
+	master.checkWorkersHealth()
+	if master.Workers[0].Broken() || job.MapTasks[1].TaskState != TASK_INPROGRESS {
+		t.Fatal("worker should not be dead before missing enough heartbeats")
+	}
+	master.checkWorkersHealth()
+	if !master.Workers[0].Broken() {
+		t.Fatal("worker should be marked unknown")
+	}
+	for i, task := range job.MapTasks {
+		if task.TaskState != TASK_IDLE || task.IMDs != nil {
+			t.Errorf("map task %v should be re-queued", i)
+		}
+	}
+
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if task.Type != rpc.Task_WAIT {
+		t.Error("dead worker should not get tasks")
+	}
+	mocks.State = WORKER_IDLE
+	master.checkWorkersHealth()
+	if master.Workers[0].Broken() {
+		t.Error("worker should be back after a successful heartbeat")
+	}
+}
+
+func TestLostWorkerKeepsFinishedJobs(t *testing.T) {
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+
+	for _, phase := range []int{PHASE_DONE, PHASE_FAILED} {
+		job := newTestJob(master, []MapTaskInfo{newMapTask()})
+		job.MapTasks[0].TaskState = TASK_COMPLETED
+		job.MapTasks[0].WorkerUUID = "uuid"
+		job.MapTasks[0].IMDs = []IMDInfo{{IP: "ip", FileName: "imd"}}
+		job.phase = phase
+	}
+	master.recoverWorkerTasks("uuid")
+	for _, job := range master.Jobs {
+		if job.MapTasks[0].TaskState != TASK_COMPLETED || job.MapTasks[0].IMDs == nil || job.phase == PHASE_MAP {
+			t.Errorf("map output of a job in phase %v should be kept", job.phase)
+		}
 	}
 }
 
+func TestSpeculativeBackup(t *testing.T) {
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid1", Ip: "ip1"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask(), newMapTask(), newMapTask(), newMapTask()})
+
+	rows := []*rpc.Counter{{Group: "input", Name: "rows", Value: 10}}
+	for i := 0; i < 3; i++ {
+		task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid1"})
+		master.ReportTask(context.Background(), &rpc.TaskResult{
+			Uuid:      "uuid1",
+			TaskUuid:  task.Uuid,
+			Result:    true,
+			Filenames: []string{"imd"},
+			Counters:  rows,
+		})
+	}
+	straggler, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid1"})
+	if task.Type != rpc.Task_WAIT {
+		t.Fatal("task running shortly should not be backed up")
+	}
+
+	job.MapTasks[3].StartTime = time.Now().Add(-2 * speculativeMinRuntime)
+	backup, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid1"})
+	if backup.Type != rpc.Task_MAP || backup.Uuid != straggler.Uuid {
+		t.Fatal("straggler should be backed up on an idle worker")
+	}
+
+	res, _ := master.ReportTask(context.Background(), &rpc.TaskResult{
+		Uuid:      "uuid1",
+		TaskUuid:  backup.Uuid,
+		Result:    true,
+		Filenames: []string{"imd-backup"},
+		Counters:  rows,
+	})
+	if !res.Result {
+		t.Error("first finished copy should commit")
+	}
+	res, _ = master.ReportTask(context.Background(), &rpc.TaskResult{
+		Uuid:      "uuid",
+		TaskUuid:  straggler.Uuid,
+		Result:    true,
+		Filenames: []string{"imd-primary"},
+		Counters:  rows,
+	})
+	if res.Result {
+		t.Error("late copy should discard its output")
+	}
+	if job.ReduceTasks[0].IMDs[3].FileName != "imd-backup" {
+		t.Error("reducer should read the output of the winning copy")
+	}
+	if n := job.counters()["input"]["rows"]; n != 40 {
+		t.Error("counters of the losing copy should not be added, got", n)
+	}
+}
+
+func TestJournalResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	files, err := createTestFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, f := range files {
+			os.Remove(f)
+		}
+	}()
+
+	master := NewMaster(2, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.journal, err = openJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+	master.split = SplitPolicy{TargetLines: 1}
+	master.Submit(JobSpec{ID: "job", Files: files[:1]})
+	done, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	master.ReportTask(context.Background(), &rpc.TaskResult{
+		Uuid:      "uuid",
+		TaskUuid:  done.Uuid,
+		Result:    true,
+		Filenames: []string{"imd"},
+	})
+	running, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	master.journal.close()
+	const downtime = 100 * time.Millisecond
+	time.Sleep(downtime)
+
+	resumed := NewMaster(2, 1).(*Master)
+	resumed.client = mocks.WorkerClient{}
+	if err := resumed.replayJournal(path); err != nil {
+		t.Fatal(err)
+	}
+	job := resumed.findJob("job")
+	if job == nil || job.phase != PHASE_MAP || len(job.MapTasks) != len(master.Jobs[0].MapTasks) {
+		t.Fatalf("expect job with %v map tasks in map phase, got %+v", len(master.Jobs[0].MapTasks), job)
+	}
+	if len(resumed.Workers) != 1 || resumed.serviceDiscovey("uuid") != "ip" {
+		t.Error("registered worker should be restored")
+	}
+	first := job.MapTasks[0]
+	if first.TaskState != TASK_COMPLETED || len(first.IMDs) != 1 || first.IMDs[0].IP != "ip" {
+		t.Errorf("finished map task should keep its output, got %+v", first)
+	}
+	if first.Duration >= downtime {
+		t.Errorf("the duration of a finished task should not include the downtime, got %v", first.Duration)
+	}
+	second := job.MapTasks[1]
+	if second.UUID != running.Uuid || second.TaskState != TASK_INPROGRESS || second.WorkerUUID != "uuid" {
+		t.Errorf("running map task should stay assigned, got %+v", second)
+	}
+
+	res, _ := resumed.ReportTask(context.Background(), &rpc.TaskResult{
+		Uuid:      "uuid",
+		TaskUuid:  running.Uuid,
+		Result:    true,
+		Filenames: []string{"imd"},
+	})
+	if !res.Result {
+		t.Error("report of a task assigned before the restart should be accepted")
+	}
+}
+
+func TestJournalReplaysLostWorker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	var err error
+	master.journal, err = openJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+	newTestJob(master, []MapTaskInfo{newMapTask()})
+	master.recordJob(master.Jobs[0])
+	master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+
+	mocks.State = WORKER_UNKNOWN
+	defer func() { mocks.State = WORKER_IDLE }()
+	for i := 0; i < 3; i++ {
+		master.checkWorkersHealth()
+	}
+	master.journal.close()
+
+	resumed := NewMaster(1, 1).(*Master)
+	resumed.client = mocks.WorkerClient{}
+	if err := resumed.replayJournal(path); err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.Workers[0].Broken() {
+		t.Error("a worker lost before the restart should stay lost")
+	}
+	if task, _ := resumed.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"}); task.Type != rpc.Task_WAIT {
+		t.Error("a lost worker should not get tasks until a heartbeat brings it back")
+	}
+	if resumed.Jobs[0].MapTasks[0].TaskState != TASK_IDLE {
+		t.Error("the task of the lost worker should be re-queued")
+	}
+}
+
+func TestRepeatedReportKeepsCommit(t *testing.T) {
+	master := NewMaster(1, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+
+	job := newTestJob(master, []MapTaskInfo{newMapTask()})
+	report := func(task *rpc.Task) bool {
+		result := &rpc.TaskResult{Uuid: "uuid", TaskUuid: task.Uuid, Result: true}
+		if task.Type == rpc.Task_MAP {
+			result.Filenames = []string{"imd-0"}
+		}
+		res, _ := master.ReportTask(context.Background(), result)
+		return res.Result
+	}
+
+	mapTask, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if !report(mapTask) || !report(mapTask) {
+		t.Fatal("both copies of the winning report should commit")
+	}
+	reduceTask, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if !report(reduceTask) {
+		t.Fatal("the only attempt should commit")
+	}
+	if job.phase != PHASE_DONE || !report(reduceTask) {
+		t.Fatal("a retried report should commit after the job is done")
+	}
+	if job.MapTasks[0].IMDs[0].FileName != "imd-0" {
+		t.Error("a retried report should not change the task output")
+	}
+}
+
+func TestSideInputs(t *testing.T) {
+	master := NewMaster(0, 1).(*Master)
+	master.client = mocks.WorkerClient{}
+	master.WorkerRegister(context.Background(), &rpc.WorkerInfo{Uuid: "uuid", Ip: "ip"})
+
+	dir := t.TempDir()
+	dim := filepath.Join(dir, "dim.tsv")
+	if err := os.WriteFile(dim, []byte("a\t1\nb\t2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("a\nb\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := master.Submit(JobSpec{ID: "missing", Files: []string{input},
+		SideInputs: SideInputs{CacheFiles: []string{filepath.Join(dir, "none.tsv")}}}); err == nil {
+		t.Error("missing cache files should be rejected")
+	}
+	if _, err := master.Submit(JobSpec{ID: "twice", Files: []string{input},
+		SideInputs: SideInputs{CacheFiles: []string{dim, dim}}}); err == nil {
+		t.Error("cache files with the same name should be rejected")
+	}
+	if _, err := master.Submit(JobSpec{ID: "job", Files: []string{input},
+		SideInputs: SideInputs{Params: map[string]string{"n": "3"}, CacheFiles: []string{dim}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	task, _ := master.RequestTask(context.Background(), &rpc.WorkerInfo{Uuid: "uuid"})
+	if task.Params["n"] != "3" || len(task.CacheFiles) != 1 || task.CacheFiles[0].Name != "dim.tsv" || task.CacheFiles[0].Size != 8 {
+		t.Fatalf("task should carry the side inputs of its job, got %v", task)
+	}
+	stream := &cacheStream{}
+	if err := master.FetchCacheFile(&rpc.CacheFileLoc{JobId: "job", Name: "dim.tsv", Offset: 4}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if stream.data != "b\t2\n" {
+		t.Errorf("expected the file from offset 4, got %q", stream.data)
+	}
+	if err := master.FetchCacheFile(&rpc.CacheFileLoc{JobId: "job", Name: "input.txt"}, stream); err == nil {
+		t.Error("only the cache files of the job should be served")
+	}
+}
+
+// cacheStream collects the chunks of a FetchCacheFile call.
+type cacheStream struct {
+	rpc.Master_FetchCacheFileServer
+	data string
+}
+
+func (s *cacheStream) Send(chunk *rpc.IMDChunk) error {
+	s.data += string(chunk.Data)
+	return nil
+}
+
 func TestEndWorkers(t *testing.T) {
 	master := NewMaster(2, 1).(*Master)
 	master.client = mocks.WorkerClient{}
 	master.endWorkers()
 }
 
+// newTestJob queues a job made of the given map tasks, skipping input splits.
+func newTestJob(ms *Master, mapTasks []MapTaskInfo) *Job {
+	job := newJob(JobSpec{NReduce: ms.numReducer})
+	job.MapTasks = mapTasks
+	job.phase = PHASE_MAP
+	ms.Jobs = append(ms.Jobs, job)
+	return job
+}
+
 func createTestFiles() ([]string, error) {
 	fileNames := []string{}
 	file1, err := os.CreateTemp("", "tmp-file")
@@ -7,9 +7,8 @@ import (
 
 type WorkerClient struct{}
 
-var Request interface{}
-var Counter int
 var Result bool
+var State int
 
 func (WorkerClient) Connect(workerIP string) (*grpc.ClientConn, rpc.WorkerClient) {
 	conn := grpc.ClientConn{}
@@ -17,30 +16,10 @@ func (WorkerClient) Connect(workerIP string) (*grpc.ClientConn, rpc.WorkerClient
 	return &conn, rpc.NewWorkerClient(&conn)
 }
 
-func (WorkerClient) Map(workerIP string, m *rpc.MapInfo) bool {
-	Counter++
-	Request = m
-	// return true in second try for FailsTolerant
-	if Counter > 1 {
-		Result = true
-	}
-	return Result
-}
-
-func (WorkerClient) Reduce(workerIP string, m *rpc.ReduceInfo) bool {
-	Counter++
-	Request = m
-	// return true in second try for FailsTolerant
-	if Counter > 1 {
-		Result = true
-	}
-	return Result
-}
-
 func (WorkerClient) End(workerIP string) bool {
 	return Result
 }
 
 func (WorkerClient) Health(workerIP string) int {
-	return 0
+	return State
 }
@@ -0,0 +1,57 @@
+package master
+
+import (
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// partitioners are the partitioners a job may ask for. The default hashes
+// keys, or calls the Partition function of the plugin if it exports one.
+var partitioners = map[string]bool{"": true, "hash": true, "range": true}
+
+// firstPhase is the phase a new job starts in. A range partitioned job first
+// runs every map task in sample mode to pick its split points.
+func (job *Job) firstPhase() int {
+	if job.Partitioner == "range" {
+		return PHASE_SAMPLE
+	}
+	return PHASE_MAP
+}
+
+// finishSampling picks the split points from the keys sampled by the map
+// tasks and starts the map phase proper.
+func (job *Job) finishSampling() {
+	job.SplitPoints = splitPoints(job.samples, job.numReducer)
+	job.samples = nil
+	for i := range job.MapTasks {
+		// The tasks run again in full, on the retry budget left from sampling.
+		old := job.MapTasks[i].TaskStatus
+		ts := newTaskStatus(old.UUID)
+		ts.Attempts, ts.Launches = old.Attempts, old.Launches
+		ts.Failures, ts.LastError = old.Failures, old.LastError
+		job.MapTasks[i].TaskStatus = ts
+	}
+	job.phase = PHASE_MAP
+	log.Info(fmt.Sprintf("[Master] Job %v sampled, split points %q", job.ID, job.SplitPoints))
+}
+
+// splitPoints returns nReduce-1 keys cutting the sampled keys into equal
+// parts. Reducer i gets the keys from split point i-1 up to, but excluding,
+// split point i.
+func splitPoints(samples map[int][]string, nReduce int) []string {
+	var keys []string
+	for _, s := range samples {
+		keys = append(keys, s...)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	points := make([]string, 0, nReduce-1)
+	for i := 1; i < nReduce; i++ {
+		points = append(points, keys[i*len(keys)/nReduce])
+	}
+	return points
+}
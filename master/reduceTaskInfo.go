@@ -6,20 +6,19 @@ import (
 )
 
 type ReduceTaskInfo struct {
-	IMDs      []IMDInfo
-	TaskState int
-	UUID      string
+	TaskStatus
+	IMDs []IMDInfo
 }
 
 type IMDInfo struct {
 	IP       string
 	FileName string
+	Size     int64
 }
 
 func newReduceTask() ReduceTaskInfo {
 	return ReduceTaskInfo{
-		UUID:      uuid.New().String(),
-		TaskState: TASK_IDLE,
+		TaskStatus: newTaskStatus(uuid.New().String()),
 	}
 }
 
@@ -14,8 +14,6 @@ import (
 
 type RpcClient interface {
 	Connect(workerIP string) (*grpc.ClientConn, rpc.WorkerClient)
-	Map(workerIP string, m *rpc.MapInfo) bool
-	Reduce(workerIP string, m *rpc.ReduceInfo) bool
 	End(workerIP string) bool
 	Health(workerIP string) int
 }
@@ -34,70 +32,38 @@ func durationFromEnv(key string, def time.Duration) time.Duration {
 	return time.Duration(secs) * time.Second
 }
 
-func (client *workerClient) Connect(workerIP string) (*grpc.ClientConn, rpc.WorkerClient) {
-	conn, err := grpc.Dial(workerIP, grpc.WithInsecure())
-	if err != nil {
-		log.Warn(err)
-		return nil, nil
+func intFromEnv(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
 	}
-
-	return conn, rpc.NewWorkerClient(conn)
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
 }
 
-func (client *workerClient) Map(workerIP string, m *rpc.MapInfo) bool {
-	conn, c := client.Connect(workerIP)
-	if conn == nil {
-		return false
+func boolFromEnv(key string, def bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
 	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(
-		context.Background(),
-		durationFromEnv("MR_MAP_RPC_TIMEOUT_SEC", 600*time.Second),
-	)
-	defer cancel()
-
-	r, err := c.Map(ctx, m)
+	b, err := strconv.ParseBool(raw)
 	if err != nil {
-		respErr, ok := status.FromError(err)
-		if ok {
-			//actual error from gRPC
-			//todo: we can improve error handling by using grpc statusCodes()
-			log.Warn("[Master]: " + respErr.Message())
-		} else {
-			log.Warn("[Master]: " + err.Error())
-		}
-		return false
+		return def
 	}
-	return r.Result
+	return b
 }
 
-func (client *workerClient) Reduce(workerIP string, m *rpc.ReduceInfo) bool {
-	conn, c := client.Connect(workerIP)
-	if conn == nil {
-		return false
-	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(
-		context.Background(),
-		durationFromEnv("MR_REDUCE_RPC_TIMEOUT_SEC", 600*time.Second),
-	)
-	defer cancel()
-
-	r, err := c.Reduce(ctx, m)
+func (client *workerClient) Connect(workerIP string) (*grpc.ClientConn, rpc.WorkerClient) {
+	conn, err := grpc.Dial(workerIP, grpc.WithInsecure())
 	if err != nil {
-		respErr, ok := status.FromError(err)
-		if ok {
-			//actual error from gRPC
-			//todo: we can improve error handling by using grpc statusCodes(respErr.Code())
-			log.Warn("[Master]: " + respErr.Message())
-		} else {
-			log.Warn("[Master]: " + err.Error())
-		}
-		return false
+		log.Warn(err)
+		return nil, nil
 	}
-	return r.Result
+
+	return conn, rpc.NewWorkerClient(conn)
 }
 
 func (client *workerClient) End(workerIP string) bool {
@@ -152,6 +118,6 @@ func (client *workerClient) Health(workerIP string) int {
 		return int(WORKER_UNKNOWN)
 	}
 
-	log.Info("[Master] Worker State ", int(r.State))
+	log.Trace("[Master] Worker State ", int(r.State))
 	return int(r.State)
 }
@@ -0,0 +1,99 @@
+package master
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// cacheChunkSize is the size of the chunks cache files are streamed in.
+const cacheChunkSize = 1 << 20
+
+// SideInputs are read-only data given to every task of a job, wherever it
+// runs. Tasks read them through worker.MrContext.
+type SideInputs struct {
+	// Params are key/value settings, read with MrContext.Param.
+	Params map[string]string
+	// CacheFiles are files on the master host, such as lookup tables or
+	// models. Each worker downloads them once per job, before its first task
+	// of the job, and tasks open them by base name with MrContext.CacheFile.
+	CacheFiles []string
+}
+
+// cacheFileInfo checks the cache files of a job and returns their names and
+// sizes for the workers.
+func cacheFileInfo(paths []string) ([]*rpc.CacheFile, error) {
+	var files []*rpc.CacheFile
+	names := make(map[string]bool)
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if names[name] {
+			return nil, fmt.Errorf("two cache files are named %v", name)
+		}
+		names[name] = true
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cache file: %v", err)
+		}
+		if !fi.Mode().IsRegular() {
+			return nil, fmt.Errorf("cache file %v is not a regular file", path)
+		}
+		files = append(files, &rpc.CacheFile{Name: name, Size: fi.Size()})
+	}
+	return files, nil
+}
+
+// cacheFilePath returns the path of the cache file of the job named name.
+func (job *Job) cacheFilePath(name string) (string, bool) {
+	for _, path := range job.CacheFiles {
+		if filepath.Base(path) == name {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// FetchCacheFile streams a cache file of a job from in.Offset. Only the files
+// the job declared are served.
+func (ms *Master) FetchCacheFile(in *rpc.CacheFileLoc, stream rpc.Master_FetchCacheFileServer) error {
+	ms.mux.Lock()
+	job := ms.findJob(in.JobId)
+	var path string
+	var ok bool
+	if job != nil {
+		path, ok = job.cacheFilePath(in.Name)
+	}
+	ms.mux.Unlock()
+	if !ok {
+		return status.Errorf(codes.NotFound, "job %v has no cache file %v", in.JobId, in.Name)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	defer f.Close()
+	if _, err := f.Seek(in.Offset, io.SeekStart); err != nil {
+		return status.Error(codes.OutOfRange, err.Error())
+	}
+	buf := make([]byte, cacheChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&rpc.IMDChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package master
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+// DefaultSplitBytes is the input size of one map task unless overridden.
+const DefaultSplitBytes = 64 << 20
+
+// SplitPolicy decides how input files are cut into map tasks. A file no
+// larger than one split becomes a single task; larger files are cut on record
+// boundaries once a split reaches TargetBytes or TargetLines.
+type SplitPolicy struct {
+	TargetBytes int64
+	TargetLines int64
+}
+
+func splitPolicyFromEnv() SplitPolicy {
+	return SplitPolicy{
+		TargetBytes: int64(intFromEnv("MR_SPLIT_BYTES", DefaultSplitBytes)),
+		TargetLines: int64(intFromEnv("MR_SPLIT_LINES", 0)),
+	}
+}
+
+// splittable tells whether files of an input format may be cut. Gzip streams
+// cannot be read from the middle and whole files are one record.
+func splittable(format string) bool {
+	return format != "gzip" && format != "whole"
+}
+
+func (p SplitPolicy) full(bytes int64, lines int64) bool {
+	return (p.TargetBytes > 0 && bytes >= p.TargetBytes) ||
+		(p.TargetLines > 0 && lines >= p.TargetLines)
+}
+
+// splitFile returns the byte ranges of file according to the policy, cut
+// after a line, or for csv after a line that closes every quote, so that no
+// record spans two splits. Empty files produce no split.
+func splitFile(file string, policy SplitPolicy, format string) ([]FileInfo, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil
+	}
+	if !splittable(format) || policy.TargetLines <= 0 && (policy.TargetBytes <= 0 || size <= policy.TargetBytes) {
+		return []FileInfo{{FileName: file, From: 0, To: int(size)}}, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var splits []FileInfo
+	var from, cursor, lines, quotes int64
+	reader := bufio.NewReaderSize(f, 1<<20)
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		cursor += int64(len(chunk))
+		if format == "csv" {
+			quotes += int64(bytes.Count(chunk, []byte{'"'}))
+		}
+		if err == bufio.ErrBufferFull {
+			// The line is longer than the buffer, keep reading it.
+			continue
+		}
+		// A newline inside a quoted CSV field does not end the record.
+		if len(chunk) > 0 && chunk[len(chunk)-1] == '\n' && quotes%2 == 0 {
+			lines++
+			if policy.full(cursor-from, lines) {
+				splits = append(splits, FileInfo{FileName: file, From: int(from), To: int(cursor)})
+				from, lines = cursor, 0
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cursor > from {
+		splits = append(splits, FileInfo{FileName: file, From: int(from), To: int(cursor)})
+	}
+	return splits, nil
+}
@@ -0,0 +1,230 @@
+package master
+
+import (
+	"time"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+// Backup copies are only launched for tasks that have run at least this long.
+const speculativeMinRuntime = time.Second
+
+// TaskStatus is the scheduling state shared by map and reduce tasks.
+type TaskStatus struct {
+	UUID       string
+	TaskState  int
+	WorkerUUID string
+	StartTime  time.Time
+	Attempts   int
+	// Launches counts the attempts handed out, backup copies included.
+	Launches int
+	// BackupUUID is the worker running a speculative copy of the task.
+	BackupUUID  string
+	BackupStart time.Time
+	// Duration is how long the winning attempt took.
+	Duration time.Duration
+	// Failures counts the attempts reported as failed, timed out or lost with
+	// their worker, LastError is the error of the latest one.
+	Failures  int
+	LastError string
+	// Records is the number of records the winning attempt wrote.
+	Records int64
+	// Counters are the user counters of the winning attempt.
+	Counters Counters
+}
+
+func newTaskStatus(uuid string) TaskStatus {
+	return TaskStatus{
+		UUID:      uuid,
+		TaskState: TASK_IDLE,
+	}
+}
+
+func (ts *TaskStatus) assign(workerUUID string) {
+	ts.TaskState = TASK_INPROGRESS
+	ts.WorkerUUID = workerUUID
+	ts.StartTime = time.Now()
+	ts.BackupUUID = ""
+	ts.Attempts++
+	ts.Launches++
+}
+
+func (ts *TaskStatus) assignBackup(workerUUID string) {
+	ts.BackupUUID = workerUUID
+	ts.BackupStart = time.Now()
+	ts.Launches++
+}
+
+// runningOn reports whether an attempt of the task is running on workerUUID.
+func (ts *TaskStatus) runningOn(workerUUID string) bool {
+	return ts.TaskState == TASK_INPROGRESS && (ts.WorkerUUID == workerUUID || ts.BackupUUID == workerUUID)
+}
+
+// runnable reports whether the task can be handed to a worker.
+func (ts *TaskStatus) runnable() bool {
+	return ts.TaskState == TASK_IDLE
+}
+
+// timedOut returns the worker of an attempt of the task, first or backup, that
+// has outlived timeout and is presumed lost with it, or "".
+func (ts *TaskStatus) timedOut(timeout time.Duration) string {
+	if ts.TaskState != TASK_INPROGRESS {
+		return ""
+	}
+	if time.Since(ts.StartTime) > timeout {
+		return ts.WorkerUUID
+	}
+	if ts.BackupUUID != "" && time.Since(ts.BackupStart) > timeout {
+		return ts.BackupUUID
+	}
+	return ""
+}
+
+// finish records the report of the attempt running on workerUUID. It returns
+// true only for the first successful copy, whose output becomes the task
+// output; failed and late copies must discard what they produced, and so must
+// an attempt the master has already given up on. at is when the report came
+// in, which is in the past when the report is replayed from the journal.
+func (ts *TaskStatus) finish(workerUUID string, ok bool, at time.Time) bool {
+	if !ts.runningOn(workerUUID) {
+		return false
+	}
+	if !ok {
+		ts.fail(workerUUID)
+		return false
+	}
+	if workerUUID == ts.BackupUUID {
+		ts.Duration = at.Sub(ts.BackupStart)
+	} else {
+		ts.Duration = at.Sub(ts.StartTime)
+	}
+	ts.TaskState = TASK_COMPLETED
+	ts.WorkerUUID = workerUUID
+	ts.BackupUUID = ""
+	return true
+}
+
+// committedBy reports whether the winning attempt of the task ran on
+// workerUUID.
+func (ts *TaskStatus) committedBy(workerUUID string) bool {
+	return ts.TaskState == TASK_COMPLETED && ts.WorkerUUID == workerUUID
+}
+
+// fail drops the attempt running on workerUUID. The task goes back to the
+// queue unless another copy of it is still running.
+func (ts *TaskStatus) fail(workerUUID string) bool {
+	if ts.TaskState != TASK_INPROGRESS {
+		return false
+	}
+	switch workerUUID {
+	case ts.BackupUUID:
+		ts.BackupUUID = ""
+	case ts.WorkerUUID:
+		if ts.BackupUUID != "" {
+			ts.WorkerUUID, ts.StartTime = ts.BackupUUID, ts.BackupStart
+			ts.BackupUUID = ""
+			return false
+		}
+		ts.TaskState = TASK_IDLE
+		return true
+	}
+	return false
+}
+
+// reopen puts a task completed by workerUUID back in the queue, when that
+// worker then failed to commit its output.
+func (ts *TaskStatus) reopen(workerUUID string) bool {
+	if !ts.committedBy(workerUUID) {
+		return false
+	}
+	ts.TaskState = TASK_IDLE
+	ts.Records, ts.Counters = 0, nil
+	return true
+}
+
+func (ts *TaskStatus) toRPC() *rpc.TaskProgress {
+	ret := &rpc.TaskProgress{
+		Attempts:     int64(ts.Attempts),
+		Worker:       ts.WorkerUUID,
+		BackupWorker: ts.BackupUUID,
+		Records:      ts.Records,
+	}
+	switch ts.TaskState {
+	case TASK_IDLE:
+		ret.State = "idle"
+		ret.Worker = ""
+		if ts.Failures > 0 {
+			ret.State = "failed"
+		}
+	case TASK_INPROGRESS:
+		ret.State = "in_progress"
+		ret.ElapsedMs = time.Since(ts.StartTime).Milliseconds()
+	case TASK_COMPLETED:
+		ret.State = "completed"
+		ret.ElapsedMs = ts.Duration.Milliseconds()
+	}
+	return ret
+}
+
+func nextTask(tasks []*TaskStatus) int {
+	for i, t := range tasks {
+		if t.runnable() {
+			return i
+		}
+	}
+	return -1
+}
+
+// speculativeTask picks a straggler to back up on workerUUID. Backups are
+// only launched once most tasks of the phase have finished, for the longest
+// running task that is much slower than the finished ones.
+func speculativeTask(tasks []*TaskStatus, workerUUID string) int {
+	if !boolFromEnv("MR_SPECULATIVE", true) || len(tasks) == 0 {
+		return -1
+	}
+	completed := 0
+	var total time.Duration
+	for _, t := range tasks {
+		if t.TaskState == TASK_COMPLETED {
+			completed++
+			total += t.Duration
+		}
+	}
+	if completed == 0 || completed*100 < len(tasks)*intFromEnv("MR_SPECULATIVE_PERCENT", 75) {
+		return -1
+	}
+	threshold := total / time.Duration(completed) * time.Duration(intFromEnv("MR_SPECULATIVE_SLOWDOWN", 2))
+	if threshold < speculativeMinRuntime {
+		threshold = speculativeMinRuntime
+	}
+
+	ret := -1
+	var longest time.Duration
+	for i, t := range tasks {
+		if t.TaskState != TASK_INPROGRESS || t.BackupUUID != "" || t.WorkerUUID == workerUUID {
+			continue
+		}
+		if elapsed := time.Since(t.StartTime); elapsed > threshold && elapsed > longest {
+			ret, longest = i, elapsed
+		}
+	}
+	return ret
+}
+
+func findTask(tasks []*TaskStatus, uuid string) int {
+	for i, t := range tasks {
+		if t.UUID == uuid {
+			return i
+		}
+	}
+	return -1
+}
+
+func allCompleted(tasks []*TaskStatus) bool {
+	for _, t := range tasks {
+		if t.TaskState != TASK_COMPLETED {
+			return false
+		}
+	}
+	return true
+}
@@ -6,6 +6,7 @@ type WorkerInfo struct {
 	UUID        string
 	IP          string
 	WorkerState int
+	missed      int
 	mux         sync.Mutex
 }
 
@@ -38,5 +39,8 @@ func (w *WorkerInfo) SetState(state int) {
 }
 
 func (w *WorkerInfo) Broken() bool {
-	return w.WorkerState == WORKER_UNKNOWN
+	w.mux.Lock()
+	ret := w.WorkerState == WORKER_UNKNOWN
+	w.mux.Unlock()
+	return ret
 }
@@ -1,48 +1,117 @@
 package master
 
 import (
-	// log "github.com/sirupsen/logrus"
-	// "sync"
+	"fmt"
+	"sync"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // If there are 3 continuous unknow, we thought that that worker is dead.
 // stop that work and make other deal with that.
 
 func (ms *Master) PeriodicHealthCheck() {
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(durationFromEnv("MR_HEARTBEAT_INTERVAL_SEC", 1*time.Second))
+	defer ticker.Stop()
 
 	for {
-		<-ticker.C
-		ms.checkWorkersHealth()
+		select {
+		case <-ticker.C:
+			ms.checkWorkersHealth()
+		case <-ms.quit:
+			return
+		}
 	}
-
 }
 
 func (ms *Master) checkWorkersHealth() {
-	// var wg sync.WaitGroup
-	// for _, worker := range ms.Workers {
-	// 	// worker.WorkerState = Health(worker.IP)
-	// 	wg.Add(1)
-	// 	go func(w WorkerInfo) {
-	// 		checkHealth(w)
-	// 		wg.Done()
-	// 	}(worker)
-	// }
-	// wg.Wait()
+	defer ms.syncJournal()
+	ms.mux.Lock()
+	uuids := make([]string, len(ms.Workers))
+	ips := make([]string, len(ms.Workers))
+	for i := range ms.Workers {
+		uuids[i] = ms.Workers[i].UUID
+		ips[i] = ms.Workers[i].getIP()
+	}
+	ms.mux.Unlock()
+
+	states := make([]int, len(ips))
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		wg.Add(1)
+		go func(i int, ip string) {
+			defer wg.Done()
+			states[i] = ms.client.Health(ip)
+		}(i, ip)
+	}
+	wg.Wait()
+
+	maxMissed := intFromEnv("MR_HEARTBEAT_MISSES", 3)
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
+	for i, uuid := range uuids {
+		id := ms.workerIndex(uuid)
+		if id < 0 {
+			continue
+		}
+		w := &ms.Workers[id]
+		if states[i] != WORKER_UNKNOWN {
+			if w.Broken() {
+				log.Info(fmt.Sprintf("[Master] Worker %v is back", uuid))
+			}
+			w.missed = 0
+			w.SetState(states[i])
+			continue
+		}
+		w.missed++
+		if w.missed >= maxMissed && !w.Broken() {
+			log.Warn(fmt.Sprintf("[Master] Worker %v missed %v heartbeats, mark it dead", uuid, w.missed))
+			w.SetState(WORKER_UNKNOWN)
+			ms.record(journalRecord{Type: journalLost, Worker: uuid})
+			ms.recoverWorkerTasks(uuid)
+		}
+	}
+	ms.expireTasks()
 }
 
-// func checkHealth(worker WorkerInfo) {
-// worker.WorkerState = Health(worker.IP)
-// state := ""
-// switch worker.WorkerState {
-// case WORKER_IDLE:
-// 	state = "Worker IDLE"
-// case WORKER_BUSY:
-// 	state = "Worker Busy"
-// case WORKER_UNKNOWN:
-// 	state = "Worker Dead"
-// }
-
-// log.Info("[Master] Worker state is :", state)
-// }
+// recoverWorkerTasks re-queues the tasks a dead worker was running, charging
+// each lost attempt like a failed one. Its finished map outputs live on the
+// same host, so they are re-executed too.
+// Jobs that are done or failed are left alone: their reducers need no more
+// input.
+func (ms *Master) recoverWorkerTasks(uuid string) {
+	for _, job := range ms.runningJobs() {
+		if job.finished() {
+			continue
+		}
+		lostMap := false
+		for i := range job.MapTasks {
+			task := &job.MapTasks[i]
+			if task.runningOn(uuid) {
+				if task.fail(uuid) {
+					log.Info(fmt.Sprintf("[Master] Re-queue Map task %v of job %v from %v", i, job.ID, uuid))
+				}
+				ms.taskFailed(job, false, i, uuid, "worker lost")
+			} else if task.TaskState == TASK_COMPLETED && task.WorkerUUID == uuid {
+				log.Info(fmt.Sprintf("[Master] Re-execute Map task %v of job %v from %v", i, job.ID, uuid))
+				task.setState(TASK_IDLE)
+				task.IMDs = nil
+				lostMap = true
+			}
+		}
+		for i := range job.ReduceTasks {
+			task := &job.ReduceTasks[i]
+			if !task.runningOn(uuid) {
+				continue
+			}
+			if task.fail(uuid) {
+				log.Info(fmt.Sprintf("[Master] Re-queue Reduce task %v of job %v from %v", i, job.ID, uuid))
+			}
+			ms.taskFailed(job, true, i, uuid, "worker lost")
+		}
+		if lostMap && job.phase == PHASE_REDUCE {
+			job.phase = PHASE_MAP
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package mapreduce
 
+import "github.com/emptyOVO/mrkit-go/master"
+
 func StartMaster(input []string, plugin string, nReducer int, nWorker int, inRAM bool) {
 	if err := StartMasterWithAddr(input, plugin, nReducer, nWorker, inRAM, MasterIP); err != nil {
 		panic(err)
@@ -7,5 +9,5 @@ func StartMaster(input []string, plugin string, nReducer int, nWorker int, inRAM
 }
 
 func StartMasterWithAddr(input []string, plugin string, nReducer int, nWorker int, inRAM bool, masterAddr string) error {
-	return startMasterWithAddr(masterAddr, input, nWorker, nReducer)
+	return startMasterWithAddr(masterAddr, input, nWorker, nReducer, master.Options{})
 }
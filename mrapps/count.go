@@ -7,26 +7,27 @@ import (
 	"github.com/emptyOVO/mrkit-go/worker"
 )
 
-// Map expects each input line in TSV format:
+// MapRecord expects each input line in TSV format:
 //
 //	id\tbiz_key\tmetric
 //
 // It emits: key=biz_key, value=1.
-func Map(filename string, contents string, ctx worker.MrContext) {
-	local := make(map[string]int, 256)
-	for _, line := range strings.Split(strings.TrimSpace(contents), "\n") {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, "\t")
-		if len(parts) < 3 {
-			continue
-		}
-		local[parts[1]]++
+// Rows with fewer fields count as input.malformed_rows.
+func MapRecord(offset string, line string, ctx worker.MrContext) {
+	if strings.TrimSpace(line) == "" {
+		return
 	}
-	for k, v := range local {
-		ctx.EmitIntermediate(k, strconv.Itoa(v))
+	parts := strings.Split(line, "\t")
+	if len(parts) < 3 {
+		ctx.IncrCounter("input", "malformed_rows", 1)
+		return
 	}
+	ctx.EmitIntermediate(parts[1], "1")
+}
+
+// Combine pre-counts rows per biz_key on the map side.
+func Combine(key string, values []string, ctx worker.MrContext) {
+	Reduce(key, values, ctx)
 }
 
 // Reduce counts rows per biz_key.
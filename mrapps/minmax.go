@@ -8,22 +8,26 @@ import (
 	"github.com/emptyOVO/mrkit-go/worker"
 )
 
-// Map expects each input line in TSV format:
+// MapRecord expects each input line in TSV format:
 //
 //	id\tbiz_key\tmetric
 //
 // It emits: key=biz_key, value=metric.
-func Map(filename string, contents string, ctx worker.MrContext) {
-	for _, line := range strings.Split(strings.TrimSpace(contents), "\n") {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, "\t")
-		if len(parts) < 3 {
-			continue
-		}
-		ctx.EmitIntermediate(parts[1], parts[2])
+// Rows with fewer fields count as input.malformed_rows; metrics that
+// are not integers count as input.invalid_metrics and are ignored by Reduce.
+func MapRecord(offset string, line string, ctx worker.MrContext) {
+	if strings.TrimSpace(line) == "" {
+		return
 	}
+	parts := strings.Split(line, "\t")
+	if len(parts) < 3 {
+		ctx.IncrCounter("input", "malformed_rows", 1)
+		return
+	}
+	if _, err := strconv.Atoi(strings.TrimSpace(parts[2])); err != nil {
+		ctx.IncrCounter("input", "invalid_metrics", 1)
+	}
+	ctx.EmitIntermediate(parts[1], parts[2])
 }
 
 // Reduce computes min/max/range per biz_key.
@@ -37,7 +41,12 @@ func Reduce(key string, values []string, ctx worker.MrContext) {
 		ctx.Emit(key, "0")
 		return
 	}
-	mode := strings.ToLower(strings.TrimSpace(os.Getenv("MYSQL_MINMAX_MODE")))
+	// The job param wins, the environment only reaches local workers.
+	mode := ctx.Param("MYSQL_MINMAX_MODE")
+	if mode == "" {
+		mode = os.Getenv("MYSQL_MINMAX_MODE")
+	}
+	mode = strings.ToLower(strings.TrimSpace(mode))
 	if mode == "" {
 		mode = "max"
 	}
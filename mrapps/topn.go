@@ -9,31 +9,40 @@ import (
 	"github.com/emptyOVO/mrkit-go/worker"
 )
 
-// Map expects each input line in TSV format:
+// MapRecord expects each input line in TSV format:
 //
 //	id\tbiz_key\tmetric
 //
 // It emits: key=biz_key, value=metric.
-func Map(filename string, contents string, ctx worker.MrContext) {
-	for _, line := range strings.Split(strings.TrimSpace(contents), "\n") {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, "\t")
-		if len(parts) < 3 {
-			continue
-		}
-		ctx.EmitIntermediate(parts[1], parts[2])
+// Rows with fewer fields count as input.malformed_rows; metrics that
+// are not integers count as input.invalid_metrics and are ignored by Reduce.
+func MapRecord(offset string, line string, ctx worker.MrContext) {
+	if strings.TrimSpace(line) == "" {
+		return
 	}
+	parts := strings.Split(line, "\t")
+	if len(parts) < 3 {
+		ctx.IncrCounter("input", "malformed_rows", 1)
+		return
+	}
+	if _, err := strconv.Atoi(strings.TrimSpace(parts[2])); err != nil {
+		ctx.IncrCounter("input", "invalid_metrics", 1)
+	}
+	ctx.EmitIntermediate(parts[1], parts[2])
 }
 
 // Reduce computes the N-th largest metric per biz_key.
-// N is controlled by MYSQL_TOPN_N (default 3).
+// N is controlled by the job param MYSQL_TOPN_N, or the variable of the same
+// name in the worker environment (default 3).
 // When N > number of values, it returns the smallest available value.
 func Reduce(key string, values []string, ctx worker.MrContext) {
 	n := 3
-	if s := strings.TrimSpace(os.Getenv("MYSQL_TOPN_N")); s != "" {
-		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+	raw := ctx.Param("MYSQL_TOPN_N")
+	if raw == "" {
+		raw = os.Getenv("MYSQL_TOPN_N")
+	}
+	if raw = strings.TrimSpace(raw); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
 			n = v
 		}
 	}
@@ -40,5 +40,25 @@ func Map(filename string, contents string, ctx worker.MrContext) {
 //
 func Reduce(key string, values []string, ctx worker.MrContext) {
 	// return the number of occurrences of this word.
-	ctx.Emit(key, strconv.Itoa(len(values)))
+	ctx.Emit(key, strconv.Itoa(sum(values)))
+}
+
+//
+// Combine pre-counts each word on the map side, so only one pair per word
+// and map task goes through the shuffle.
+//
+func Combine(key string, values []string, ctx worker.MrContext) {
+	ctx.Emit(key, strconv.Itoa(sum(values)))
+}
+
+func sum(values []string) int {
+	total := 0
+	for _, v := range values {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		total += n
+	}
+	return total
 }
@@ -21,6 +21,20 @@ type MasterClient interface {
 	WorkerRegister(ctx context.Context, in *WorkerInfo, opts ...grpc.CallOption) (*RegisterResult, error)
 	// IMD = InterMeDiate
 	UpdateIMDInfo(ctx context.Context, in *IMDInfo, opts ...grpc.CallOption) (*UpdateResult, error)
+	// Idle workers pull their next task and report back once it is done.
+	RequestTask(ctx context.Context, in *WorkerInfo, opts ...grpc.CallOption) (*Task, error)
+	ReportTask(ctx context.Context, in *TaskResult, opts ...grpc.CallOption) (*UpdateResult, error)
+	// Reducers report intermediate files they could not fetch.
+	ReportFetchFailure(ctx context.Context, in *FetchFailure, opts ...grpc.CallOption) (*UpdateResult, error)
+	// Jobs share the registered workers and run in submission order.
+	SubmitJob(ctx context.Context, in *JobSpec, opts ...grpc.CallOption) (*JobInfo, error)
+	// WaitJob blocks until the job is done.
+	WaitJob(ctx context.Context, in *JobInfo, opts ...grpc.CallOption) (*JobInfo, error)
+	// GetJobStatus reports the progress of a job. An empty id picks the
+	// oldest job still running, or the latest one if all are over.
+	GetJobStatus(ctx context.Context, in *JobInfo, opts ...grpc.CallOption) (*JobStatus, error)
+	// Workers download the cache files of a job before running its tasks.
+	FetchCacheFile(ctx context.Context, in *CacheFileLoc, opts ...grpc.CallOption) (Master_FetchCacheFileClient, error)
 }
 
 type masterClient struct {
@@ -49,6 +63,92 @@ func (c *masterClient) UpdateIMDInfo(ctx context.Context, in *IMDInfo, opts ...g
 	return out, nil
 }
 
+func (c *masterClient) RequestTask(ctx context.Context, in *WorkerInfo, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, "/Master/RequestTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *masterClient) ReportTask(ctx context.Context, in *TaskResult, opts ...grpc.CallOption) (*UpdateResult, error) {
+	out := new(UpdateResult)
+	err := c.cc.Invoke(ctx, "/Master/ReportTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *masterClient) ReportFetchFailure(ctx context.Context, in *FetchFailure, opts ...grpc.CallOption) (*UpdateResult, error) {
+	out := new(UpdateResult)
+	err := c.cc.Invoke(ctx, "/Master/ReportFetchFailure", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *masterClient) SubmitJob(ctx context.Context, in *JobSpec, opts ...grpc.CallOption) (*JobInfo, error) {
+	out := new(JobInfo)
+	err := c.cc.Invoke(ctx, "/Master/SubmitJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *masterClient) WaitJob(ctx context.Context, in *JobInfo, opts ...grpc.CallOption) (*JobInfo, error) {
+	out := new(JobInfo)
+	err := c.cc.Invoke(ctx, "/Master/WaitJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *masterClient) GetJobStatus(ctx context.Context, in *JobInfo, opts ...grpc.CallOption) (*JobStatus, error) {
+	out := new(JobStatus)
+	err := c.cc.Invoke(ctx, "/Master/GetJobStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *masterClient) FetchCacheFile(ctx context.Context, in *CacheFileLoc, opts ...grpc.CallOption) (Master_FetchCacheFileClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Master_ServiceDesc.Streams[0], "/Master/FetchCacheFile", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &masterFetchCacheFileClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Master_FetchCacheFileClient interface {
+	Recv() (*IMDChunk, error)
+	grpc.ClientStream
+}
+
+type masterFetchCacheFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *masterFetchCacheFileClient) Recv() (*IMDChunk, error) {
+	m := new(IMDChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // MasterServer is the server API for Master service.
 // All implementations must embed UnimplementedMasterServer
 // for forward compatibility
@@ -56,6 +156,20 @@ type MasterServer interface {
 	WorkerRegister(context.Context, *WorkerInfo) (*RegisterResult, error)
 	// IMD = InterMeDiate
 	UpdateIMDInfo(context.Context, *IMDInfo) (*UpdateResult, error)
+	// Idle workers pull their next task and report back once it is done.
+	RequestTask(context.Context, *WorkerInfo) (*Task, error)
+	ReportTask(context.Context, *TaskResult) (*UpdateResult, error)
+	// Reducers report intermediate files they could not fetch.
+	ReportFetchFailure(context.Context, *FetchFailure) (*UpdateResult, error)
+	// Jobs share the registered workers and run in submission order.
+	SubmitJob(context.Context, *JobSpec) (*JobInfo, error)
+	// WaitJob blocks until the job is done.
+	WaitJob(context.Context, *JobInfo) (*JobInfo, error)
+	// GetJobStatus reports the progress of a job. An empty id picks the
+	// oldest job still running, or the latest one if all are over.
+	GetJobStatus(context.Context, *JobInfo) (*JobStatus, error)
+	// Workers download the cache files of a job before running its tasks.
+	FetchCacheFile(*CacheFileLoc, Master_FetchCacheFileServer) error
 	mustEmbedUnimplementedMasterServer()
 }
 
@@ -69,6 +183,27 @@ func (UnimplementedMasterServer) WorkerRegister(context.Context, *WorkerInfo) (*
 func (UnimplementedMasterServer) UpdateIMDInfo(context.Context, *IMDInfo) (*UpdateResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateIMDInfo not implemented")
 }
+func (UnimplementedMasterServer) RequestTask(context.Context, *WorkerInfo) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestTask not implemented")
+}
+func (UnimplementedMasterServer) ReportTask(context.Context, *TaskResult) (*UpdateResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportTask not implemented")
+}
+func (UnimplementedMasterServer) ReportFetchFailure(context.Context, *FetchFailure) (*UpdateResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportFetchFailure not implemented")
+}
+func (UnimplementedMasterServer) SubmitJob(context.Context, *JobSpec) (*JobInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitJob not implemented")
+}
+func (UnimplementedMasterServer) WaitJob(context.Context, *JobInfo) (*JobInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaitJob not implemented")
+}
+func (UnimplementedMasterServer) GetJobStatus(context.Context, *JobInfo) (*JobStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJobStatus not implemented")
+}
+func (UnimplementedMasterServer) FetchCacheFile(*CacheFileLoc, Master_FetchCacheFileServer) error {
+	return status.Errorf(codes.Unimplemented, "method FetchCacheFile not implemented")
+}
 func (UnimplementedMasterServer) mustEmbedUnimplementedMasterServer() {}
 
 // UnsafeMasterServer may be embedded to opt out of forward compatibility for this service.
@@ -118,6 +253,135 @@ func _Master_UpdateIMDInfo_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Master_RequestTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WorkerInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MasterServer).RequestTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Master/RequestTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MasterServer).RequestTask(ctx, req.(*WorkerInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Master_ReportTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskResult)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MasterServer).ReportTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Master/ReportTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MasterServer).ReportTask(ctx, req.(*TaskResult))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Master_ReportFetchFailure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchFailure)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MasterServer).ReportFetchFailure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Master/ReportFetchFailure",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MasterServer).ReportFetchFailure(ctx, req.(*FetchFailure))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Master_SubmitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobSpec)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MasterServer).SubmitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Master/SubmitJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MasterServer).SubmitJob(ctx, req.(*JobSpec))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Master_WaitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MasterServer).WaitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Master/WaitJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MasterServer).WaitJob(ctx, req.(*JobInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Master_GetJobStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MasterServer).GetJobStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Master/GetJobStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MasterServer).GetJobStatus(ctx, req.(*JobInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Master_FetchCacheFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CacheFileLoc)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MasterServer).FetchCacheFile(m, &masterFetchCacheFileServer{stream})
+}
+
+type Master_FetchCacheFileServer interface {
+	Send(*IMDChunk) error
+	grpc.ServerStream
+}
+
+type masterFetchCacheFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *masterFetchCacheFileServer) Send(m *IMDChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Master_ServiceDesc is the grpc.ServiceDesc for Master service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -133,7 +397,37 @@ var Master_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateIMDInfo",
 			Handler:    _Master_UpdateIMDInfo_Handler,
 		},
+		{
+			MethodName: "RequestTask",
+			Handler:    _Master_RequestTask_Handler,
+		},
+		{
+			MethodName: "ReportTask",
+			Handler:    _Master_ReportTask_Handler,
+		},
+		{
+			MethodName: "ReportFetchFailure",
+			Handler:    _Master_ReportFetchFailure_Handler,
+		},
+		{
+			MethodName: "SubmitJob",
+			Handler:    _Master_SubmitJob_Handler,
+		},
+		{
+			MethodName: "WaitJob",
+			Handler:    _Master_WaitJob_Handler,
+		},
+		{
+			MethodName: "GetJobStatus",
+			Handler:    _Master_GetJobStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FetchCacheFile",
+			Handler:       _Master_FetchCacheFile_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc/master.proto",
 }
@@ -63,7 +63,7 @@ func (x WorkerState_State) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use WorkerState_State.Descriptor instead.
 func (WorkerState_State) EnumDescriptor() ([]byte, []int) {
-	return file_rpc_worker_proto_rawDescGZIP(), []int{9, 0}
+	return file_rpc_worker_proto_rawDescGZIP(), []int{10, 0}
 }
 
 type Empty struct {
@@ -164,7 +164,23 @@ type MapInfo struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Files []*MapFileInfo `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+	Files   []*MapFileInfo `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+	Id      int64          `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	JobId   string         `protobuf:"bytes,3,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	NReduce int64          `protobuf:"varint,4,opt,name=n_reduce,json=nReduce,proto3" json:"n_reduce,omitempty"`
+	// Codec of the intermediate files: none, gzip or flate.
+	Compression string `protobuf:"bytes,5,opt,name=compression,proto3" json:"compression,omitempty"`
+	// "range" partitions by split_points instead of hashing keys.
+	Partitioner string   `protobuf:"bytes,6,opt,name=partitioner,proto3" json:"partitioner,omitempty"`
+	SplitPoints []string `protobuf:"bytes,7,rep,name=split_points,json=splitPoints,proto3" json:"split_points,omitempty"`
+	// Non-zero asks for a sample of up to sample_keys map output keys, from
+	// about sample_bytes of the input, instead of intermediate files.
+	SampleKeys  int64 `protobuf:"varint,8,opt,name=sample_keys,json=sampleKeys,proto3" json:"sample_keys,omitempty"`
+	SampleBytes int64 `protobuf:"varint,9,opt,name=sample_bytes,json=sampleBytes,proto3" json:"sample_bytes,omitempty"`
+	// Record reader of the input: text, tsv, csv, jsonl, gzip or whole.
+	InputFormat string `protobuf:"bytes,10,opt,name=input_format,json=inputFormat,proto3" json:"input_format,omitempty"`
+	// Directory of the named outputs of the task.
+	OutputDir string `protobuf:"bytes,11,opt,name=output_dir,json=outputDir,proto3" json:"output_dir,omitempty"`
 }
 
 func (x *MapInfo) Reset() {
@@ -206,6 +222,76 @@ func (x *MapInfo) GetFiles() []*MapFileInfo {
 	return nil
 }
 
+func (x *MapInfo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MapInfo) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *MapInfo) GetNReduce() int64 {
+	if x != nil {
+		return x.NReduce
+	}
+	return 0
+}
+
+func (x *MapInfo) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
+func (x *MapInfo) GetPartitioner() string {
+	if x != nil {
+		return x.Partitioner
+	}
+	return ""
+}
+
+func (x *MapInfo) GetSplitPoints() []string {
+	if x != nil {
+		return x.SplitPoints
+	}
+	return nil
+}
+
+func (x *MapInfo) GetSampleKeys() int64 {
+	if x != nil {
+		return x.SampleKeys
+	}
+	return 0
+}
+
+func (x *MapInfo) GetSampleBytes() int64 {
+	if x != nil {
+		return x.SampleBytes
+	}
+	return 0
+}
+
+func (x *MapInfo) GetInputFormat() string {
+	if x != nil {
+		return x.InputFormat
+	}
+	return ""
+}
+
+func (x *MapInfo) GetOutputDir() string {
+	if x != nil {
+		return x.OutputDir
+	}
+	return ""
+}
+
 type MapFileInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -274,7 +360,12 @@ type ReduceInfo struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Files []*ReduceFileInfo `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+	Files     []*ReduceFileInfo `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+	Id        int64             `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	JobId     string            `protobuf:"bytes,3,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	OutputDir string            `protobuf:"bytes,4,opt,name=output_dir,json=outputDir,proto3" json:"output_dir,omitempty"`
+	// Codec of the reducer's own runs on disk.
+	Compression string `protobuf:"bytes,5,opt,name=compression,proto3" json:"compression,omitempty"`
 }
 
 func (x *ReduceInfo) Reset() {
@@ -316,6 +407,34 @@ func (x *ReduceInfo) GetFiles() []*ReduceFileInfo {
 	return nil
 }
 
+func (x *ReduceInfo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ReduceInfo) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *ReduceInfo) GetOutputDir() string {
+	if x != nil {
+		return x.OutputDir
+	}
+	return ""
+}
+
+func (x *ReduceInfo) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
 type ReduceFileInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -377,6 +496,8 @@ type IMDLoc struct {
 	unknownFields protoimpl.UnknownFields
 
 	Filename string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	// Byte offset to start reading at, for FetchIMD.
+	Offset int64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
 }
 
 func (x *IMDLoc) Reset() {
@@ -418,6 +539,60 @@ func (x *IMDLoc) GetFilename() string {
 	return ""
 }
 
+func (x *IMDLoc) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type IMDChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *IMDChunk) Reset() {
+	*x = IMDChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_worker_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IMDChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IMDChunk) ProtoMessage() {}
+
+func (x *IMDChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_worker_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IMDChunk.ProtoReflect.Descriptor instead.
+func (*IMDChunk) Descriptor() ([]byte, []int) {
+	return file_rpc_worker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *IMDChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 type JSONKVs struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -429,7 +604,7 @@ type JSONKVs struct {
 func (x *JSONKVs) Reset() {
 	*x = JSONKVs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_worker_proto_msgTypes[7]
+		mi := &file_rpc_worker_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -442,7 +617,7 @@ func (x *JSONKVs) String() string {
 func (*JSONKVs) ProtoMessage() {}
 
 func (x *JSONKVs) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_worker_proto_msgTypes[7]
+	mi := &file_rpc_worker_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -455,7 +630,7 @@ func (x *JSONKVs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use JSONKVs.ProtoReflect.Descriptor instead.
 func (*JSONKVs) Descriptor() ([]byte, []int) {
-	return file_rpc_worker_proto_rawDescGZIP(), []int{7}
+	return file_rpc_worker_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *JSONKVs) GetKvs() string {
@@ -477,7 +652,7 @@ type KV struct {
 func (x *KV) Reset() {
 	*x = KV{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_worker_proto_msgTypes[8]
+		mi := &file_rpc_worker_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -490,7 +665,7 @@ func (x *KV) String() string {
 func (*KV) ProtoMessage() {}
 
 func (x *KV) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_worker_proto_msgTypes[8]
+	mi := &file_rpc_worker_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -503,7 +678,7 @@ func (x *KV) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KV.ProtoReflect.Descriptor instead.
 func (*KV) Descriptor() ([]byte, []int) {
-	return file_rpc_worker_proto_rawDescGZIP(), []int{8}
+	return file_rpc_worker_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *KV) GetKey() string {
@@ -531,7 +706,7 @@ type WorkerState struct {
 func (x *WorkerState) Reset() {
 	*x = WorkerState{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_worker_proto_msgTypes[9]
+		mi := &file_rpc_worker_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -544,7 +719,7 @@ func (x *WorkerState) String() string {
 func (*WorkerState) ProtoMessage() {}
 
 func (x *WorkerState) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_worker_proto_msgTypes[9]
+	mi := &file_rpc_worker_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -557,7 +732,7 @@ func (x *WorkerState) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkerState.ProtoReflect.Descriptor instead.
 func (*WorkerState) Descriptor() ([]byte, []int) {
-	return file_rpc_worker_proto_rawDescGZIP(), []int{9}
+	return file_rpc_worker_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *WorkerState) GetState() WorkerState_State {
@@ -575,45 +750,76 @@ var file_rpc_worker_proto_rawDesc = []byte{
 	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20,
 	0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x73,
 	0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x22, 0x2d, 0x0a, 0x07, 0x4d, 0x61, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x22, 0x0a, 0x05,
-	0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x4d, 0x61,
-	0x70, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73,
+	0x74, 0x22, 0xdc, 0x02, 0x0a, 0x07, 0x4d, 0x61, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x22, 0x0a,
+	0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x4d,
+	0x61, 0x70, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65,
+	0x73, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x5f, 0x72, 0x65,
+	0x64, 0x75, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x6e, 0x52, 0x65, 0x64,
+	0x75, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x70, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x70, 0x61, 0x72, 0x74,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x65, 0x72, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x70, 0x6c, 0x69, 0x74,
+	0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x73,
+	0x70, 0x6c, 0x69, 0x74, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x61,
+	0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0a, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73,
+	0x61, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0b, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x21,
+	0x0a, 0x0c, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61,
+	0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x64, 0x69, 0x72, 0x18,
+	0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x44, 0x69, 0x72,
 	0x22, 0x4d, 0x0a, 0x0b, 0x4d, 0x61, 0x70, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12,
 	0x1a, 0x0a, 0x08, 0x46, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x08, 0x46, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x46,
 	0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x46, 0x72, 0x6f, 0x6d, 0x12,
 	0x0e, 0x0a, 0x02, 0x54, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x54, 0x6f, 0x22,
-	0x33, 0x0a, 0x0a, 0x52, 0x65, 0x64, 0x75, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x25, 0x0a,
-	0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x52,
-	0x65, 0x64, 0x75, 0x63, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x66,
-	0x69, 0x6c, 0x65, 0x73, 0x22, 0x3c, 0x0a, 0x0e, 0x52, 0x65, 0x64, 0x75, 0x63, 0x65, 0x46, 0x69,
-	0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x02, 0x69, 0x70, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61,
-	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61,
-	0x6d, 0x65, 0x22, 0x24, 0x0a, 0x06, 0x49, 0x4d, 0x44, 0x4c, 0x6f, 0x63, 0x12, 0x1a, 0x0a, 0x08,
-	0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
-	0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x1b, 0x0a, 0x07, 0x4a, 0x53, 0x4f, 0x4e,
-	0x4b, 0x56, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x76, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x03, 0x6b, 0x76, 0x73, 0x22, 0x2c, 0x0a, 0x02, 0x4b, 0x56, 0x12, 0x10, 0x0a, 0x03, 0x6b,
-	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x22, 0x54, 0x0a, 0x0b, 0x57, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x53, 0x74, 0x61,
-	0x74, 0x65, 0x12, 0x28, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x12, 0x2e, 0x57, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x2e,
-	0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x22, 0x1b, 0x0a, 0x05,
-	0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x44, 0x4c, 0x45, 0x10, 0x00, 0x12,
-	0x08, 0x0a, 0x04, 0x42, 0x55, 0x53, 0x59, 0x10, 0x01, 0x32, 0x9a, 0x01, 0x0a, 0x06, 0x57, 0x6f,
-	0x72, 0x6b, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x03, 0x4d, 0x61, 0x70, 0x12, 0x08, 0x2e, 0x4d, 0x61,
-	0x70, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1e,
-	0x0a, 0x06, 0x52, 0x65, 0x64, 0x75, 0x63, 0x65, 0x12, 0x0b, 0x2e, 0x52, 0x65, 0x64, 0x75, 0x63,
-	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1f,
-	0x0a, 0x0a, 0x47, 0x65, 0x74, 0x49, 0x4d, 0x44, 0x44, 0x61, 0x74, 0x61, 0x12, 0x07, 0x2e, 0x49,
-	0x4d, 0x44, 0x4c, 0x6f, 0x63, 0x1a, 0x08, 0x2e, 0x4a, 0x53, 0x4f, 0x4e, 0x4b, 0x56, 0x73, 0x12,
-	0x15, 0x0a, 0x03, 0x45, 0x6e, 0x64, 0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x06,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x1e, 0x0a, 0x06, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68,
-	0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0c, 0x2e, 0x57, 0x6f, 0x72, 0x6b, 0x65,
-	0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x2f, 0x3b, 0x72, 0x70, 0x63,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x9b, 0x01, 0x0a, 0x0a, 0x52, 0x65, 0x64, 0x75, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x25,
+	0x0a, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
+	0x52, 0x65, 0x64, 0x75, 0x63, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05,
+	0x66, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a,
+	0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x64, 0x69, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x44, 0x69, 0x72, 0x12, 0x20, 0x0a, 0x0b, 0x63,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x3c, 0x0a,
+	0x0e, 0x52, 0x65, 0x64, 0x75, 0x63, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x70, 0x12,
+	0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x3c, 0x0a, 0x06, 0x49,
+	0x4d, 0x44, 0x4c, 0x6f, 0x63, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0x1e, 0x0a, 0x08, 0x49, 0x4d, 0x44,
+	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x1b, 0x0a, 0x07, 0x4a, 0x53, 0x4f,
+	0x4e, 0x4b, 0x56, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x76, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x76, 0x73, 0x22, 0x2c, 0x0a, 0x02, 0x4b, 0x56, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x22, 0x54, 0x0a, 0x0b, 0x57, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x28, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x12, 0x2e, 0x57, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x22, 0x1b, 0x0a,
+	0x05, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x44, 0x4c, 0x45, 0x10, 0x00,
+	0x12, 0x08, 0x0a, 0x04, 0x42, 0x55, 0x53, 0x59, 0x10, 0x01, 0x32, 0xc1, 0x01, 0x0a, 0x06, 0x57,
+	0x6f, 0x72, 0x6b, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x03, 0x4d, 0x61, 0x70, 0x12, 0x08, 0x2e, 0x4d,
+	0x61, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x1e, 0x0a, 0x06, 0x52, 0x65, 0x64, 0x75, 0x63, 0x65, 0x12, 0x0b, 0x2e, 0x52, 0x65, 0x64, 0x75,
+	0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x24, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x49, 0x4d, 0x44, 0x44, 0x61, 0x74, 0x61, 0x12, 0x07, 0x2e,
+	0x49, 0x4d, 0x44, 0x4c, 0x6f, 0x63, 0x1a, 0x08, 0x2e, 0x4a, 0x53, 0x4f, 0x4e, 0x4b, 0x56, 0x73,
+	0x22, 0x03, 0x88, 0x02, 0x01, 0x12, 0x20, 0x0a, 0x08, 0x46, 0x65, 0x74, 0x63, 0x68, 0x49, 0x4d,
+	0x44, 0x12, 0x07, 0x2e, 0x49, 0x4d, 0x44, 0x4c, 0x6f, 0x63, 0x1a, 0x09, 0x2e, 0x49, 0x4d, 0x44,
+	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x30, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x45, 0x6e, 0x64, 0x12, 0x06,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x1e,
+	0x0a, 0x06, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x1a, 0x0c, 0x2e, 0x57, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x42, 0x08,
+	0x5a, 0x06, 0x2e, 0x2f, 0x3b, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -629,7 +835,7 @@ func file_rpc_worker_proto_rawDescGZIP() []byte {
 }
 
 var file_rpc_worker_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_rpc_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_rpc_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_rpc_worker_proto_goTypes = []interface{}{
 	(WorkerState_State)(0), // 0: WorkerState.State
 	(*Empty)(nil),          // 1: Empty
@@ -639,9 +845,10 @@ var file_rpc_worker_proto_goTypes = []interface{}{
 	(*ReduceInfo)(nil),     // 5: ReduceInfo
 	(*ReduceFileInfo)(nil), // 6: ReduceFileInfo
 	(*IMDLoc)(nil),         // 7: IMDLoc
-	(*JSONKVs)(nil),        // 8: JSONKVs
-	(*KV)(nil),             // 9: KV
-	(*WorkerState)(nil),    // 10: WorkerState
+	(*IMDChunk)(nil),       // 8: IMDChunk
+	(*JSONKVs)(nil),        // 9: JSONKVs
+	(*KV)(nil),             // 10: KV
+	(*WorkerState)(nil),    // 11: WorkerState
 }
 var file_rpc_worker_proto_depIdxs = []int32{
 	4,  // 0: MapInfo.files:type_name -> MapFileInfo
@@ -650,15 +857,17 @@ var file_rpc_worker_proto_depIdxs = []int32{
 	3,  // 3: Worker.Map:input_type -> MapInfo
 	5,  // 4: Worker.Reduce:input_type -> ReduceInfo
 	7,  // 5: Worker.GetIMDData:input_type -> IMDLoc
-	1,  // 6: Worker.End:input_type -> Empty
-	1,  // 7: Worker.Health:input_type -> Empty
-	2,  // 8: Worker.Map:output_type -> Result
-	2,  // 9: Worker.Reduce:output_type -> Result
-	8,  // 10: Worker.GetIMDData:output_type -> JSONKVs
-	1,  // 11: Worker.End:output_type -> Empty
-	10, // 12: Worker.Health:output_type -> WorkerState
-	8,  // [8:13] is the sub-list for method output_type
-	3,  // [3:8] is the sub-list for method input_type
+	7,  // 6: Worker.FetchIMD:input_type -> IMDLoc
+	1,  // 7: Worker.End:input_type -> Empty
+	1,  // 8: Worker.Health:input_type -> Empty
+	2,  // 9: Worker.Map:output_type -> Result
+	2,  // 10: Worker.Reduce:output_type -> Result
+	9,  // 11: Worker.GetIMDData:output_type -> JSONKVs
+	8,  // 12: Worker.FetchIMD:output_type -> IMDChunk
+	1,  // 13: Worker.End:output_type -> Empty
+	11, // 14: Worker.Health:output_type -> WorkerState
+	9,  // [9:15] is the sub-list for method output_type
+	3,  // [3:9] is the sub-list for method input_type
 	3,  // [3:3] is the sub-list for extension type_name
 	3,  // [3:3] is the sub-list for extension extendee
 	0,  // [0:3] is the sub-list for field type_name
@@ -755,7 +964,7 @@ func file_rpc_worker_proto_init() {
 			}
 		}
 		file_rpc_worker_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*JSONKVs); i {
+			switch v := v.(*IMDChunk); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -767,7 +976,7 @@ func file_rpc_worker_proto_init() {
 			}
 		}
 		file_rpc_worker_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KV); i {
+			switch v := v.(*JSONKVs); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -779,6 +988,18 @@ func file_rpc_worker_proto_init() {
 			}
 		}
 		file_rpc_worker_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KV); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_worker_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*WorkerState); i {
 			case 0:
 				return &v.state
@@ -797,7 +1018,7 @@ func file_rpc_worker_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_rpc_worker_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   10,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -21,7 +21,13 @@ type WorkerClient interface {
 	Map(ctx context.Context, in *MapInfo, opts ...grpc.CallOption) (*Result, error)
 	Reduce(ctx context.Context, in *ReduceInfo, opts ...grpc.CallOption) (*Result, error)
 	// IMD = InterMeDiate
+	// GetIMDData returns a whole intermediate file as "key\tvalue\n" lines,
+	// for reducers that predate FetchIMD.
+	// Deprecated: Do not use.
 	GetIMDData(ctx context.Context, in *IMDLoc, opts ...grpc.CallOption) (*JSONKVs, error)
+	// FetchIMD streams an intermediate file in chunks, starting at offset so
+	// an interrupted fetch can resume.
+	FetchIMD(ctx context.Context, in *IMDLoc, opts ...grpc.CallOption) (Worker_FetchIMDClient, error)
 	// rpc HealthCheck (IMDInfo) returns (UpdateResult);
 	End(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
 	Health(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*WorkerState, error)
@@ -53,6 +59,7 @@ func (c *workerClient) Reduce(ctx context.Context, in *ReduceInfo, opts ...grpc.
 	return out, nil
 }
 
+// Deprecated: Do not use.
 func (c *workerClient) GetIMDData(ctx context.Context, in *IMDLoc, opts ...grpc.CallOption) (*JSONKVs, error) {
 	out := new(JSONKVs)
 	err := c.cc.Invoke(ctx, "/Worker/GetIMDData", in, out, opts...)
@@ -62,6 +69,38 @@ func (c *workerClient) GetIMDData(ctx context.Context, in *IMDLoc, opts ...grpc.
 	return out, nil
 }
 
+func (c *workerClient) FetchIMD(ctx context.Context, in *IMDLoc, opts ...grpc.CallOption) (Worker_FetchIMDClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Worker_ServiceDesc.Streams[0], "/Worker/FetchIMD", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &workerFetchIMDClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Worker_FetchIMDClient interface {
+	Recv() (*IMDChunk, error)
+	grpc.ClientStream
+}
+
+type workerFetchIMDClient struct {
+	grpc.ClientStream
+}
+
+func (x *workerFetchIMDClient) Recv() (*IMDChunk, error) {
+	m := new(IMDChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *workerClient) End(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
 	err := c.cc.Invoke(ctx, "/Worker/End", in, out, opts...)
@@ -87,7 +126,13 @@ type WorkerServer interface {
 	Map(context.Context, *MapInfo) (*Result, error)
 	Reduce(context.Context, *ReduceInfo) (*Result, error)
 	// IMD = InterMeDiate
+	// GetIMDData returns a whole intermediate file as "key\tvalue\n" lines,
+	// for reducers that predate FetchIMD.
+	// Deprecated: Do not use.
 	GetIMDData(context.Context, *IMDLoc) (*JSONKVs, error)
+	// FetchIMD streams an intermediate file in chunks, starting at offset so
+	// an interrupted fetch can resume.
+	FetchIMD(*IMDLoc, Worker_FetchIMDServer) error
 	// rpc HealthCheck (IMDInfo) returns (UpdateResult);
 	End(context.Context, *Empty) (*Empty, error)
 	Health(context.Context, *Empty) (*WorkerState, error)
@@ -107,6 +152,9 @@ func (UnimplementedWorkerServer) Reduce(context.Context, *ReduceInfo) (*Result,
 func (UnimplementedWorkerServer) GetIMDData(context.Context, *IMDLoc) (*JSONKVs, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetIMDData not implemented")
 }
+func (UnimplementedWorkerServer) FetchIMD(*IMDLoc, Worker_FetchIMDServer) error {
+	return status.Errorf(codes.Unimplemented, "method FetchIMD not implemented")
+}
 func (UnimplementedWorkerServer) End(context.Context, *Empty) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method End not implemented")
 }
@@ -180,6 +228,27 @@ func _Worker_GetIMDData_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Worker_FetchIMD_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(IMDLoc)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WorkerServer).FetchIMD(m, &workerFetchIMDServer{stream})
+}
+
+type Worker_FetchIMDServer interface {
+	Send(*IMDChunk) error
+	grpc.ServerStream
+}
+
+type workerFetchIMDServer struct {
+	grpc.ServerStream
+}
+
+func (x *workerFetchIMDServer) Send(m *IMDChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Worker_End_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
@@ -244,6 +313,12 @@ var Worker_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Worker_Health_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FetchIMD",
+			Handler:       _Worker_FetchIMD_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "rpc/worker.proto",
 }
@@ -2,9 +2,15 @@ package mapreduce
 
 import (
 	"sync"
+
+	"github.com/emptyOVO/mrkit-go/master"
 )
 
 var MasterIP string = ":10000"
+
+// JournalPath and Resume configure the master state journal, see ParseArg.
+var JournalPath string
+var Resume bool
 var runtimeMu sync.Mutex
 
 func StartSingleMachineJob(input []string, plugin string, nReducer int, nWorker int, inRAM bool) {
@@ -14,22 +20,56 @@ func StartSingleMachineJob(input []string, plugin string, nReducer int, nWorker
 }
 
 func StartSingleMachineJobWithAddr(input []string, plugin string, nReducer int, nWorker int, inRAM bool, masterAddr string) error {
+	_, err := RunSingleMachineJob(input, plugin, nReducer, nWorker, inRAM, masterAddr, master.SideInputs{})
+	return err
+}
+
+// RunSingleMachineJob runs a job with the given side inputs in this process
+// like StartSingleMachineJobWithAddr and returns the totals of its user
+// counters.
+func RunSingleMachineJob(input []string, plugin string, nReducer int, nWorker int, inRAM bool, masterAddr string, side master.SideInputs) (master.Counters, error) {
+	return runSingleMachine(input, nWorker, nReducer, pluginJobFuncs(plugin), inRAM, masterAddr, side)
+}
+
+// StartSingleMachineApp runs a job in this process like StartSingleMachineJob,
+// with the functions of an app registered with worker.Register instead of a
+// plugin.
+func StartSingleMachineApp(input []string, app string, nReducer int, nWorker int, inRAM bool) {
+	if err := StartSingleMachineAppWithAddr(input, app, nReducer, nWorker, inRAM, MasterIP); err != nil {
+		panic(err)
+	}
+}
+
+func StartSingleMachineAppWithAddr(input []string, app string, nReducer int, nWorker int, inRAM bool, masterAddr string) error {
+	_, err := RunSingleMachineApp(input, app, nReducer, nWorker, inRAM, masterAddr, master.SideInputs{})
+	return err
+}
+
+// RunSingleMachineApp runs a job with the given side inputs like
+// StartSingleMachineAppWithAddr and returns the totals of its user counters.
+func RunSingleMachineApp(input []string, app string, nReducer int, nWorker int, inRAM bool, masterAddr string, side master.SideInputs) (master.Counters, error) {
+	return runSingleMachine(input, nWorker, nReducer, jobFuncs{app: app}, inRAM, masterAddr, side)
+}
+
+func runSingleMachine(input []string, nWorker int, nReducer int, funcs jobFuncs, inRAM bool, masterAddr string, side master.SideInputs) (master.Counters, error) {
+	counters := make(master.Counters)
 	if len(input) == 0 {
-		return nil
+		return counters, nil
 	}
 	runtimeMu.Lock()
 	defer runtimeMu.Unlock()
 	MasterIP = masterAddr
-	return singleMachineJob(input, nWorker, nReducer, plugin, inRAM, masterAddr)
+	err := singleMachineJob(input, nWorker, nReducer, funcs, inRAM, masterAddr, master.Options{Counters: counters, SideInputs: side})
+	return counters, err
 }
 
-func singleMachineJob(input []string, nWorker int, nReducer int, plugin string, storeInRAM bool, masterAddr string) error {
+func singleMachineJob(input []string, nWorker int, nReducer int, funcs jobFuncs, storeInRAM bool, masterAddr string, opts master.Options) error {
 	var wg sync.WaitGroup
 	errCh := make(chan error, 2)
 
 	wg.Add(1)
 	go func() {
-		if err := startMasterWithAddr(masterAddr, input, nWorker, nReducer); err != nil {
+		if err := startMasterWithAddr(masterAddr, input, nWorker, nReducer, opts); err != nil {
 			errCh <- err
 		}
 		wg.Done()
@@ -37,7 +77,7 @@ func singleMachineJob(input []string, nWorker int, nReducer int, plugin string,
 
 	wg.Add(1)
 	go func() {
-		if err := startSingleMachineWorkerWithMaster(masterAddr, plugin, nWorker, nReducer, storeInRAM); err != nil {
+		if err := startSingleMachineWorkerWithMaster(masterAddr, funcs, nWorker, nReducer, storeInRAM); err != nil {
 			errCh <- err
 		}
 		wg.Done()
@@ -0,0 +1,69 @@
+package mapreduce
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+// WatchJob prints a progress table of a job every interval until the job is
+// over, or once if interval is not positive.
+func WatchJob(ctx context.Context, masterAddr string, jobID string, interval time.Duration, w io.Writer) error {
+	for {
+		st, err := JobStatus(ctx, masterAddr, jobID)
+		if err != nil {
+			return err
+		}
+		printJobStatus(w, st)
+		if interval <= 0 || st.Phase == "done" || st.Phase == "failed" {
+			return nil
+		}
+		// Stick to the job first shown, the master may move on to another.
+		jobID = st.Id
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func printJobStatus(w io.Writer, st *rpc.JobStatus) {
+	done := map[string]int{}
+	total := map[string]int{}
+	for _, t := range st.Tasks {
+		total[t.Kind]++
+		if t.State == "completed" {
+			done[t.Kind]++
+		}
+	}
+	fmt.Fprintf(w, "Job %v  phase: %v  map: %v/%v  reduce: %v/%v\n",
+		st.Id, st.Phase, done["Map"], total["Map"], done["Reduce"], total["Reduce"])
+	if f := st.Failure; f != nil {
+		fmt.Fprintf(w, "Failed: %v task %v after %v attempts: %v\n", f.Kind, f.Task, f.Attempts, f.Error)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tTASK\tSTATE\tATTEMPTS\tWORKER\tELAPSED\tRECORDS\tBYTES")
+	for _, t := range st.Tasks {
+		worker := t.Worker
+		if t.BackupWorker != "" {
+			worker += " (backup " + t.BackupWorker + ")"
+		}
+		if worker == "" {
+			worker = "-"
+		}
+		elapsed := (time.Duration(t.ElapsedMs) * time.Millisecond).String()
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			t.Kind, t.Id, t.State, t.Attempts, worker, elapsed, t.Records, t.Bytes)
+	}
+	tw.Flush()
+	for _, c := range st.Counters {
+		fmt.Fprintf(w, "Counter %v.%v = %v\n", c.Group, c.Name, c.Value)
+	}
+}
@@ -1,7 +1,7 @@
 package mapreduce
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"net"
 	"os"
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/emptyOVO/mrkit-go/master"
 	"github.com/emptyOVO/mrkit-go/worker"
@@ -49,6 +50,11 @@ It supports multiple workers threads on a single machine and multiple processes
 				os.Exit(2)
 			}
 
+			if Resume && JournalPath == "" {
+				fmt.Fprintln(os.Stderr, "--resume requires --journal")
+				os.Exit(2)
+			}
+
 			plugin = pluginFiles[0]
 			files = tempFiles
 			MasterIP = ":" + strconv.Itoa(int(port))
@@ -63,6 +69,8 @@ It supports multiple workers threads on a single machine and multiple processes
 	rootCmd.PersistentFlags().Int64VarP(&nWorker, "worker", "w", 4, "Number of Workers(for master node)\nID of worker(for worker node)")
 	rootCmd.PersistentFlags().Int64Var(&port, "port", 10000, "Port number")
 	rootCmd.PersistentFlags().BoolVarP(&inRAM, "inRAM", "m", true, "Whether write the intermediate file in RAM")
+	rootCmd.PersistentFlags().StringVar(&JournalPath, "journal", "", "Master state journal file(for master node)")
+	rootCmd.PersistentFlags().BoolVar(&Resume, "resume", false, "Resume the job recorded in the journal(for master node)")
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -71,19 +79,84 @@ It supports multiple workers threads on a single machine and multiple processes
 	return files, plugin, int(nReducer), int(nWorker), inRAM
 }
 
-func startSingleMachineWorker(plugin string, nWorker int, nReducer int, storeInRAM bool) {
-	if err := startSingleMachineWorkerWithMaster(MasterIP, plugin, nWorker, nReducer, storeInRAM); err != nil {
-		panic(err)
+// ParseServeArg parses the flags of a long-lived master and returns its
+// address.
+func ParseServeArg() string {
+	var port int64
+	var rootCmd = &cobra.Command{
+		Use:   "mapreduce-master",
+		Short: "Long-lived MapReduce master serving jobs on a shared worker pool",
+		Run: func(cmd *cobra.Command, args []string) {
+			if Resume && JournalPath == "" {
+				fmt.Fprintln(os.Stderr, "--resume requires --journal")
+				os.Exit(2)
+			}
+			MasterIP = ":" + strconv.Itoa(int(port))
+		},
 	}
-}
 
-func startSingleMachineWorkerWithMaster(masterAddr string, plugin string, nWorker int, nReducer int, storeInRAM bool) error {
-	if nWorker < nReducer {
-		return fmt.Errorf("Need more worker!")
+	rootCmd.PersistentFlags().Int64Var(&port, "port", 10000, "Port number")
+	rootCmd.PersistentFlags().StringVar(&JournalPath, "journal", "", "Master state journal file")
+	rootCmd.PersistentFlags().BoolVar(&Resume, "resume", false, "Resume the jobs recorded in the journal")
+
+	var addr string
+	var interval time.Duration
+	var statusCmd = &cobra.Command{
+		Use:   "status [job-id]",
+		Short: "Print the progress of a job, by default the one the master is running",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if addr == "" {
+				addr = ":" + strconv.Itoa(int(port))
+			}
+			jobID := ""
+			if len(args) > 0 {
+				jobID = args[0]
+			}
+			if err := WatchJob(context.Background(), addr, jobID, interval, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		},
+	}
+	statusCmd.Flags().StringVar(&addr, "addr", "", "Master address, :<port> when empty")
+	statusCmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "Refresh interval, 0 prints once")
+	rootCmd.AddCommand(statusCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
+	return MasterIP
+}
 
+// jobFuncs tells where workers get their job functions: a plugin file, or
+// an app registered with worker.Register.
+type jobFuncs struct {
+	plugin string
+	app    string
+}
+
+func pluginJobFuncs(plugin string) jobFuncs {
 	pluginFile, _ := filepath.Abs(plugin)
+	return jobFuncs{plugin: pluginFile}
+}
 
+func (f jobFuncs) startWorker(nReducer int, addr string, storeInRAM bool) error {
+	if f.app != "" {
+		return worker.StartAppWorker(f.app, nReducer, addr, storeInRAM)
+	}
+	return worker.StartWorker(f.plugin, nReducer, addr, storeInRAM)
+}
+
+func startSingleMachineWorker(plugin string, nWorker int, nReducer int, storeInRAM bool) {
+	if err := startSingleMachineWorkerWithMaster(MasterIP, pluginJobFuncs(plugin), nWorker, nReducer, storeInRAM); err != nil {
+		panic(err)
+	}
+}
+
+func startSingleMachineWorkerWithMaster(masterAddr string, funcs jobFuncs, nWorker int, nReducer int, storeInRAM bool) error {
 	var wg sync.WaitGroup
 	worker.Init(masterAddr)
 	basePort := masterPort(masterAddr)
@@ -96,7 +169,7 @@ func startSingleMachineWorkerWithMaster(masterAddr string, plugin string, nWorke
 			defer wg.Done()
 			// Keep each worker on a disjoint candidate sequence to avoid collisions.
 			start := basePort + i0 + 1
-			if err := startWorkerWithRetryE(pluginFile, nReducer, start, nWorker, storeInRAM); err != nil {
+			if err := startWorkerWithRetryE(funcs, nReducer, start, nWorker, storeInRAM); err != nil {
 				errCh <- err
 			}
 		}(i)
@@ -111,12 +184,14 @@ func startSingleMachineWorkerWithMaster(masterAddr string, plugin string, nWorke
 }
 
 func startMaster(input []string, nWorker int, nReducer int) {
-	if err := startMasterWithAddr(MasterIP, input, nWorker, nReducer); err != nil {
+	if err := startMasterWithAddr(MasterIP, input, nWorker, nReducer, master.Options{}); err != nil {
 		panic(err)
 	}
 }
 
-func startMasterWithAddr(masterAddr string, input []string, nWorker int, nReducer int) error {
+// startMasterWithAddr runs the master of a single job. opts gets the journal
+// settings of the package.
+func startMasterWithAddr(masterAddr string, input []string, nWorker int, nReducer int, opts master.Options) error {
 	inputFiles := []string{}
 	for _, s := range input {
 		f, _ := filepath.Abs(s)
@@ -124,27 +199,27 @@ func startMasterWithAddr(masterAddr string, input []string, nWorker int, nReduce
 	}
 
 	var wg sync.WaitGroup
+	var runErr error
 	// Start master
 	// master.StartMaster(os.Args[1:], nReducer, MasterIP)
 	wg.Add(1)
 	go func() {
-		master.StartMaster(inputFiles, nWorker, nReducer, masterAddr)
+		opts.Journal, opts.Resume = JournalPath, Resume
+		runErr = master.StartMasterWithOptions(inputFiles, nWorker, nReducer, masterAddr, opts)
 		wg.Done()
 	}()
 
 	wg.Wait()
-	return nil
+	return runErr
 }
 
 func startWorker(plugin string, id int, nReducer int, storeInRAM bool) {
-	if err := startWorkerWithMaster(MasterIP, plugin, id, nReducer, storeInRAM); err != nil {
+	if err := startWorkerWithMaster(MasterIP, pluginJobFuncs(plugin), id, nReducer, storeInRAM); err != nil {
 		panic(err)
 	}
 }
 
-func startWorkerWithMaster(masterAddr string, plugin string, id int, nReducer int, storeInRAM bool) error {
-	pluginFile, _ := filepath.Abs(plugin)
-
+func startWorkerWithMaster(masterAddr string, funcs jobFuncs, id int, nReducer int, storeInRAM bool) error {
 	var wg sync.WaitGroup
 	worker.Init(masterAddr)
 	basePort := masterPort(masterAddr)
@@ -155,7 +230,7 @@ func startWorkerWithMaster(masterAddr string, plugin string, id int, nReducer in
 	go func() {
 		defer wg.Done()
 		start := basePort + id + 1
-		runErr = startWorkerWithRetryE(pluginFile, nReducer, start, 1, storeInRAM)
+		runErr = startWorkerWithRetryE(funcs, nReducer, start, 1, storeInRAM)
 	}()
 
 	wg.Wait()
@@ -183,12 +258,12 @@ func masterPort(masterAddr string) int {
 }
 
 func startWorkerWithRetry(pluginFile string, nReducer int, startPort int, step int, storeInRAM bool) {
-	if err := startWorkerWithRetryE(pluginFile, nReducer, startPort, step, storeInRAM); err != nil {
+	if err := startWorkerWithRetryE(jobFuncs{plugin: pluginFile}, nReducer, startPort, step, storeInRAM); err != nil {
 		panic(err)
 	}
 }
 
-func startWorkerWithRetryE(pluginFile string, nReducer int, startPort int, step int, storeInRAM bool) error {
+func startWorkerWithRetryE(funcs jobFuncs, nReducer int, startPort int, step int, storeInRAM bool) error {
 	const maxAttempts = 128
 	if step <= 0 {
 		step = 1
@@ -196,7 +271,7 @@ func startWorkerWithRetryE(pluginFile string, nReducer int, startPort int, step
 	for i := 0; i < maxAttempts; i++ {
 		port := startPort + i*step
 		addr := fmt.Sprintf(":%d", port)
-		if err := startWorkerOnce(pluginFile, nReducer, addr, storeInRAM); err != nil {
+		if err := startWorkerOnce(funcs, nReducer, addr, storeInRAM); err != nil {
 			msg := err.Error()
 			if strings.Contains(msg, "address already in use") {
 				fmt.Printf("worker listen %s occupied, trying next port\n", addr)
@@ -209,19 +284,6 @@ func startWorkerWithRetryE(pluginFile string, nReducer int, startPort int, step
 	return fmt.Errorf("unable to find available worker port from %d after %d attempts", startPort, maxAttempts)
 }
 
-func startWorkerOnce(pluginFile string, nReducer int, addr string, storeInRAM bool) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			switch v := r.(type) {
-			case error:
-				err = v
-			case string:
-				err = errors.New(v)
-			default:
-				err = fmt.Errorf("%v", r)
-			}
-		}
-	}()
-	worker.StartWorker(pluginFile, nReducer, addr, storeInRAM)
-	return nil
+func startWorkerOnce(funcs jobFuncs, nReducer int, addr string, storeInRAM bool) error {
+	return funcs.startWorker(nReducer, addr, storeInRAM)
 }
@@ -0,0 +1,96 @@
+package worker
+
+import "fmt"
+
+// combineIterator runs the plugin Combine function over each group of equal
+// keys of a sorted iterator, yielding what it emits in place of the group.
+type combineIterator struct {
+	it       kvIterator
+	combinef ReduceFormat
+	head     KV
+	hasHead  bool
+	started  bool
+	out      []KV
+}
+
+// withCombiner wraps it with combinef, or returns it as is when the plugin
+// has no Combine function.
+func withCombiner(it kvIterator, combinef ReduceFormat) kvIterator {
+	if combinef == nil {
+		return it
+	}
+	return &combineIterator{it: it, combinef: combinef}
+}
+
+func (c *combineIterator) next() (KV, bool, error) {
+	var err error
+	if !c.started {
+		c.head, c.hasHead, err = c.it.next()
+		if err != nil {
+			return KV{}, false, err
+		}
+		c.started = true
+	}
+	for len(c.out) == 0 {
+		if !c.hasHead {
+			return KV{}, false, nil
+		}
+		key := c.head.Key
+		var values []string
+		for c.hasHead && c.head.Key == key {
+			values = append(values, c.head.Value)
+			if c.head, c.hasHead, err = c.it.next(); err != nil {
+				return KV{}, false, err
+			}
+		}
+		if c.out, err = combine(c.combinef, key, values); err != nil {
+			return KV{}, false, err
+		}
+	}
+	kv := c.out[0]
+	c.out = c.out[1:]
+	return kv, true, nil
+}
+
+// withTask has combinef see the attempt it runs for.
+func withTask(combinef ReduceFormat, task *TaskInfo) ReduceFormat {
+	if combinef == nil || task == nil {
+		return combinef
+	}
+	return func(key string, values []string, ctx MrContext) {
+		ctx.task = task
+		combinef(key, values, ctx)
+	}
+}
+
+// combine calls combinef on one group and collects what it emits. A combiner
+// must keep the key it is given, since its output stays in the sorted
+// partition of that key.
+func combine(combinef ReduceFormat, key string, values []string) ([]KV, error) {
+	ctx := MrContext{Chan: make(chan KV, 16)}
+	var panicErr error
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicErr = fmt.Errorf("combine %v panic: %v", key, r)
+			}
+			close(ctx.Chan)
+		}()
+		combinef(key, values, ctx)
+	}()
+	var out []KV
+	var err error
+	for kv := range ctx.Chan {
+		if kv.output != "" && err == nil {
+			err = fmt.Errorf("combine %v emitted to output %v", key, kv.output)
+		}
+		if kv.Key != key && err == nil {
+			err = fmt.Errorf("combine %v emitted key %v", key, kv.Key)
+		}
+		out = append(out, kv)
+	}
+	if panicErr != nil {
+		return nil, panicErr
+	}
+	return out, err
+}
@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CompareFormat is the optional Compare or GroupCompare function of a plugin.
+// Like strings.Compare it returns a negative number, zero or a positive
+// number when a sorts before, with or after b.
+type CompareFormat (func(string, string) int)
+
+// keyOrder sorts keys with the plugin Compare function, byte-wise if it has
+// none, and groups them for Reduce with GroupCompare, by Compare if it has
+// none. Sorting runs in several goroutines of a task and cannot return an
+// error, so a panic of a plugin function is kept and reported by err.
+type keyOrder struct {
+	comparef CompareFormat
+	groupf   CompareFormat
+	mux      sync.Mutex
+	panicErr error
+}
+
+func newKeyOrder(comparef CompareFormat, groupf CompareFormat) *keyOrder {
+	return &keyOrder{comparef: comparef, groupf: groupf}
+}
+
+// byteOrder is the order of tasks whose plugin has no comparator.
+func byteOrder() *keyOrder {
+	return newKeyOrder(nil, nil)
+}
+
+func (o *keyOrder) less(a string, b string) bool {
+	return o.compare(a, b) < 0
+}
+
+func (o *keyOrder) compare(a string, b string) int {
+	if o.comparef == nil {
+		return strings.Compare(a, b)
+	}
+	return o.call("Compare", o.comparef, a, b)
+}
+
+// sameGroup tells whether a and b go to the same Reduce call.
+func (o *keyOrder) sameGroup(a string, b string) bool {
+	if o.groupf == nil {
+		return o.compare(a, b) == 0
+	}
+	return o.call("GroupCompare", o.groupf, a, b) == 0
+}
+
+func (o *keyOrder) call(name string, f CompareFormat, a string, b string) (ret int) {
+	defer func() {
+		if r := recover(); r != nil {
+			o.mux.Lock()
+			if o.panicErr == nil {
+				o.panicErr = fmt.Errorf("%v %q %q panic: %v", name, a, b, r)
+			}
+			o.mux.Unlock()
+			ret = 0
+		}
+	}()
+	return f(a, b)
+}
+
+// err returns the first panic of a plugin comparator.
+func (o *keyOrder) err() error {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	return o.panicErr
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSecondarySortOrder(t *testing.T) {
+	user := func(key string) string { return strings.SplitN(key, "|", 2)[0] }
+	// Sort by user, then by timestamp, newest first.
+	compare := func(a, b string) int {
+		if c := strings.Compare(user(a), user(b)); c != 0 {
+			return c
+		}
+		ta, _ := strconv.Atoi(strings.SplitN(a, "|", 2)[1])
+		tb, _ := strconv.Atoi(strings.SplitN(b, "|", 2)[1])
+		return tb - ta
+	}
+	group := func(a, b string) int { return strings.Compare(user(a), user(b)) }
+	order := newKeyOrder(compare, group)
+
+	buffer := newMapOutputBuffer(1, 128, nil, imdCodecs[0], order)
+	defer buffer.close()
+	for _, ts := range []int{5, 100, 20, 3, 42, 7} {
+		for _, u := range []string{"bob", "amy"} {
+			if err := buffer.add(0, newKV(fmt.Sprintf("%v|%v", u, ts), strconv.Itoa(ts))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if buffer.spills() == 0 {
+		t.Fatal("expected the buffer to spill")
+	}
+	its, err := buffer.iterators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	prev := ""
+	for {
+		kv, ok, err := its[0].next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		if prev == "" || !order.sameGroup(prev, kv.Key) {
+			got = append(got, "|")
+		}
+		got = append(got, kv.Value)
+		prev = kv.Key
+	}
+	if want := "[| 100 42 20 7 5 3 | 100 42 20 7 5 3]"; fmt.Sprint(got) != want {
+		t.Fatalf("expected groups %v, got %v", want, got)
+	}
+	if order.err() != nil {
+		t.Fatal(order.err())
+	}
+
+	broken := newKeyOrder(func(a, b string) int { panic("bad key") }, nil)
+	if broken.less("a", "b"); broken.err() == nil {
+		t.Fatal("expected a comparator panic to be kept")
+	}
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+type counterKey struct {
+	group string
+	name  string
+}
+
+// taskCounters collects the user counters of one task attempt. The map
+// goroutines of a task share it. A nil taskCounters drops every increment,
+// for contexts whose counts would not mean anything, such as Combine, which
+// runs any number of times, and sampling.
+type taskCounters struct {
+	mux    sync.Mutex
+	values map[counterKey]int64
+}
+
+func newTaskCounters() *taskCounters {
+	return &taskCounters{values: make(map[counterKey]int64)}
+}
+
+func (c *taskCounters) incr(group string, name string, delta int64) {
+	if c == nil {
+		return
+	}
+	c.mux.Lock()
+	c.values[counterKey{group, name}] += delta
+	c.mux.Unlock()
+}
+
+// toRPC returns the counters sorted by group and name.
+func (c *taskCounters) toRPC() []*rpc.Counter {
+	if c == nil {
+		return nil
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	ret := make([]*rpc.Counter, 0, len(c.values))
+	for k, v := range c.values {
+		ret = append(ret, &rpc.Counter{Group: k.group, Name: k.name, Value: v})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Group != ret[j].Group {
+			return ret[i].Group < ret[j].Group
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTaskCounters(t *testing.T) {
+	counting := func(key string, values []string, ctx MrContext) {
+		ctx.IncrCounter("reduce", "values", int64(len(values)))
+		ctx.Emit(key, "x")
+	}
+	counters := newTaskCounters()
+	var buf bytes.Buffer
+	for _, key := range []string{"a", "b"} {
+		if _, err := callReduce(counting, key, []string{"1", "2"}, &buf, counters, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := counters.toRPC()
+	if len(got) != 1 || got[0].Group != "reduce" || got[0].Name != "values" || got[0].Value != 4 {
+		t.Fatalf("expected reduce.values=4, got %v", got)
+	}
+	// Combine runs any number of times, so its increments are dropped.
+	if _, err := combine(counting, "a", []string{"1"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,7 +1,9 @@
 package worker
 
 type MrContext struct {
-	Chan chan KV
+	Chan     chan KV
+	counters *taskCounters
+	task     *TaskInfo
 }
 
 func newMrContext() MrContext {
@@ -17,3 +19,10 @@ func (mc *MrContext) EmitIntermediate(key string, value string) {
 func (mc *MrContext) Emit(key string, value string) {
 	mc.Chan <- newKV(key, value)
 }
+
+// IncrCounter adds delta to the counter name of group. The master sums the
+// counters of the attempt that wins each task of the job; increments made in
+// Combine are dropped.
+func (mc *MrContext) IncrCounter(group string, name string, delta int64) {
+	mc.counters.incr(group, name, delta)
+}
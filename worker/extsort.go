@@ -0,0 +1,357 @@
+package worker
+
+import (
+	"bytes"
+	"container/heap"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// kvOverhead approximates the memory a buffered pair takes besides its bytes.
+const kvOverhead = 32
+
+// kvIterator yields pairs in key order. next returns false once drained.
+type kvIterator interface {
+	next() (KV, bool, error)
+}
+
+// externalSorter merges the intermediate pairs of a reduce task within a
+// memory budget. Each added batch is one sorted run, which map outputs
+// already are. Runs are kept in memory until the budget is reached, then
+// merged into one run file on local disk; a fetched output too large for the
+// budget goes to disk as it arrives. iterator merges all runs back.
+type externalSorter struct {
+	budget int64
+	codec  imdCodec
+	order  *keyOrder
+	dir    string
+	mem    [][]KV
+	size   int64
+	runs   []string
+	open   []*os.File
+}
+
+func newExternalSorter(budget int64, codec imdCodec, order *keyOrder) *externalSorter {
+	return &externalSorter{budget: budget, codec: codec, order: order}
+}
+
+// reduceMemoryBudget is the memory a reducer may use to sort its partition,
+// MR_REDUCE_MEMORY_MB megabytes.
+func reduceMemoryBudget() int64 {
+	return int64(intFromEnv("MR_REDUCE_MEMORY_MB", 256)) << 20
+}
+
+// add adds a batch of pairs, sorting it first unless it is already sorted.
+func (s *externalSorter) add(kvs []KV) error {
+	if len(kvs) == 0 {
+		return nil
+	}
+	less := func(i, j int) bool { return s.order.less(kvs[i].Key, kvs[j].Key) }
+	if !sort.SliceIsSorted(kvs, less) {
+		sort.Slice(kvs, less)
+	}
+	s.mem = append(s.mem, kvs)
+	for _, kv := range kvs {
+		s.size += int64(len(kv.Key)+len(kv.Value)) + kvOverhead
+	}
+	if s.size >= s.budget {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill merges the runs in memory into one run on disk.
+func (s *externalSorter) spill() error {
+	if len(s.mem) == 0 {
+		return nil
+	}
+	if err := s.makeDir(); err != nil {
+		return err
+	}
+	it, err := newMergeIterator(sliceIterators(s.mem), s.order)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(s.dir, "run-"+strconv.Itoa(len(s.runs)))
+	if _, err := writeRun(name, it, s.codec); err != nil {
+		return err
+	}
+	s.runs = append(s.runs, name)
+	s.mem = nil
+	s.size = 0
+	return nil
+}
+
+func (s *externalSorter) makeDir() error {
+	if s.dir != "" {
+		return nil
+	}
+	dir, err := os.MkdirTemp("", "mr-reduce-")
+	if err != nil {
+		return err
+	}
+	s.dir = dir
+	return nil
+}
+
+// runWriter takes one sorted map output as it is fetched. The bytes are held
+// in memory while they fit in what is left of the budget and go to a run file
+// on local disk once they do not, so a partition never has to be received
+// whole before it is sorted. A compressed output that fits, but whose pairs
+// do not once decoded, goes to disk when it is closed.
+type runWriter struct {
+	s   *externalSorter
+	buf bytes.Buffer
+	f   *os.File
+}
+
+func (s *externalSorter) newRunWriter() *runWriter {
+	return &runWriter{s: s}
+}
+
+func (rw *runWriter) Write(p []byte) (int, error) {
+	if rw.f == nil && rw.s.size+int64(rw.buf.Len()+len(p)) < rw.s.budget {
+		return rw.buf.Write(p)
+	}
+	if err := rw.toDisk(); err != nil {
+		return 0, err
+	}
+	return rw.f.Write(p)
+}
+
+// toDisk moves what was received so far to a run file, where the rest of the
+// output goes too.
+func (rw *runWriter) toDisk() error {
+	if rw.f != nil {
+		return nil
+	}
+	if err := rw.s.makeDir(); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(rw.s.dir, "fetch-")
+	if err != nil {
+		return err
+	}
+	rw.s.open = append(rw.s.open, f)
+	rw.f = f
+	_, err = rw.buf.WriteTo(f)
+	return err
+}
+
+// close adds the received output to the sorter, as a run in memory or as the
+// run file it was written to. Either way every block is verified first, so a
+// corrupt output fails the fetch rather than the reduce.
+func (rw *runWriter) close() error {
+	if rw.f == nil {
+		kvs, ok, err := rw.s.decodeWithin(rw.buf.Bytes())
+		if err != nil {
+			return err
+		}
+		if ok {
+			return rw.s.add(kvs)
+		}
+		if err := rw.toDisk(); err != nil {
+			return err
+		}
+	}
+	if err := rw.f.Close(); err != nil {
+		return err
+	}
+	if err := verifyRun(rw.f.Name()); err != nil {
+		return err
+	}
+	rw.s.runs = append(rw.s.runs, rw.f.Name())
+	return nil
+}
+
+// decodeWithin decodes a map output held in memory, unless its pairs do not
+// fit in what is left of the budget. Compressed outputs take several times
+// their size once decoded.
+func (s *externalSorter) decodeWithin(raw []byte) ([]KV, bool, error) {
+	r := newIMDReader(bytes.NewReader(raw))
+	var kvs []KV
+	size := s.size
+	for {
+		kv, ok, err := r.next()
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			return kvs, true, nil
+		}
+		size += int64(len(kv.Key)+len(kv.Value)) + kvOverhead
+		if size >= s.budget {
+			return nil, false, nil
+		}
+		kvs = append(kvs, kv)
+	}
+}
+
+// verifyRun reads a run file through to its end marker.
+func verifyRun(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := newIMDReader(f)
+	for {
+		_, ok, err := r.next()
+		if err != nil || !ok {
+			return err
+		}
+	}
+}
+
+// spilled reports whether any run went to disk.
+func (s *externalSorter) spilled() bool {
+	return len(s.runs) > 0
+}
+
+// iterator returns every added pair in key order.
+func (s *externalSorter) iterator() (kvIterator, error) {
+	its := sliceIterators(s.mem)
+	for _, name := range s.runs {
+		it, err := s.openRun(name)
+		if err != nil {
+			return nil, err
+		}
+		its = append(its, it)
+	}
+	return newMergeIterator(its, s.order)
+}
+
+func (s *externalSorter) openRun(name string) (kvIterator, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	s.open = append(s.open, f)
+	return newIMDReader(f), nil
+}
+
+// close removes the runs.
+func (s *externalSorter) close() {
+	for _, f := range s.open {
+		f.Close()
+	}
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+// writeRun writes the pairs of it to a new file in the intermediate format,
+// compressed with codec, and returns how many it wrote.
+func writeRun(name string, it kvIterator, codec imdCodec) (int64, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return 0, err
+	}
+	w, err := newIMDWriter(f, codec)
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	var n int64
+	for {
+		kv, ok, err := it.next()
+		if err == nil && ok {
+			err = w.write(kv)
+		}
+		if err != nil {
+			f.Close()
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		n++
+	}
+	if err := w.close(); err != nil {
+		f.Close()
+		return 0, err
+	}
+	return n, f.Close()
+}
+
+func sliceIterators(runs [][]KV) []kvIterator {
+	its := make([]kvIterator, 0, len(runs))
+	for _, kvs := range runs {
+		its = append(its, &sliceIterator{kvs: kvs})
+	}
+	return its
+}
+
+type sliceIterator struct {
+	kvs []KV
+	i   int
+}
+
+func (it *sliceIterator) next() (KV, bool, error) {
+	if it.i >= len(it.kvs) {
+		return KV{}, false, nil
+	}
+	it.i++
+	return it.kvs[it.i-1], true, nil
+}
+
+// mergeIterator does a k-way merge of iterators sorted in order.
+type mergeIterator struct {
+	heads mergeHeap
+}
+
+type mergeHead struct {
+	kv KV
+	it kvIterator
+}
+
+type mergeHeap struct {
+	heads []mergeHead
+	order *keyOrder
+}
+
+func (h *mergeHeap) Len() int           { return len(h.heads) }
+func (h *mergeHeap) Less(i, j int) bool { return h.order.less(h.heads[i].kv.Key, h.heads[j].kv.Key) }
+func (h *mergeHeap) Swap(i, j int)      { h.heads[i], h.heads[j] = h.heads[j], h.heads[i] }
+func (h *mergeHeap) Push(x interface{}) { h.heads = append(h.heads, x.(mergeHead)) }
+func (h *mergeHeap) Pop() interface{} {
+	x := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return x
+}
+
+func newMergeIterator(its []kvIterator, order *keyOrder) (*mergeIterator, error) {
+	m := &mergeIterator{heads: mergeHeap{order: order}}
+	for _, it := range its {
+		kv, ok, err := it.next()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			m.heads.heads = append(m.heads.heads, mergeHead{kv: kv, it: it})
+		}
+	}
+	heap.Init(&m.heads)
+	return m, nil
+}
+
+func (m *mergeIterator) next() (KV, bool, error) {
+	if m.heads.Len() == 0 {
+		return KV{}, false, nil
+	}
+	head := &m.heads.heads[0]
+	ret := head.kv
+	kv, ok, err := head.it.next()
+	if err != nil {
+		return KV{}, false, err
+	}
+	if ok {
+		head.kv = kv
+		heap.Fix(&m.heads, 0)
+	} else {
+		heap.Pop(&m.heads)
+	}
+	return ret, true, nil
+}
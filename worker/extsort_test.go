@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExternalSorterSpillsAndMerges(t *testing.T) {
+	sorter := newExternalSorter(256, imdCodecs[0], byteOrder())
+	defer sorter.close()
+	for chunk := 0; chunk < 10; chunk++ {
+		var kvs []KV
+		for i := 0; i < 20; i++ {
+			kvs = append(kvs, newKV(fmt.Sprintf("k%03d", (i*37+chunk*11)%50), fmt.Sprint(chunk)))
+		}
+		if err := sorter.add(kvs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !sorter.spilled() {
+		t.Fatal("expected the sorter to spill runs past its budget")
+	}
+
+	it, err := sorter.iterator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	prev := ""
+	for {
+		kv, ok, err := it.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		if kv.Key < prev {
+			t.Fatalf("keys out of order: %q after %q", kv.Key, prev)
+		}
+		prev = kv.Key
+		count++
+	}
+	if count != 200 {
+		t.Fatalf("expected 200 pairs, got %d", count)
+	}
+}
+
+func TestRunWriterStreamsLargeOutputToDisk(t *testing.T) {
+	sorter := newExternalSorter(256, imdCodecs[0], byteOrder())
+	defer sorter.close()
+	large := []KV{}
+	for i := 0; i < 40; i++ {
+		large = append(large, newKV(fmt.Sprintf("b%03d", i), "2"))
+	}
+	outputs := [][]byte{
+		encodeIMD(t, []KV{newKV("a", "1"), newKV("c", "1")}, imdCodecs[0]),
+		encodeIMD(t, large, imdCodecs[0]),
+	}
+	for _, out := range outputs {
+		rw := sorter.newRunWriter()
+		// Write in chunks that cut records, as FetchIMD does.
+		for len(out) > 0 {
+			n := 7
+			if n > len(out) {
+				n = len(out)
+			}
+			if _, err := rw.Write(out[:n]); err != nil {
+				t.Fatal(err)
+			}
+			out = out[n:]
+		}
+		if err := rw.close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sorter.mem) != 1 || len(sorter.runs) != 1 {
+		t.Fatalf("expected one run in memory and one on disk, got %d and %d", len(sorter.mem), len(sorter.runs))
+	}
+
+	it, err := sorter.iterator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for {
+		kv, ok, err := it.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		keys = append(keys, kv.Key)
+	}
+	if len(keys) != 42 || keys[0] != "a" || keys[1] != "b000" || keys[41] != "c" {
+		t.Fatalf("unexpected merged keys %v", keys)
+	}
+}
+
+func TestRunWriterBudgetsDecodedPairs(t *testing.T) {
+	var kvs []KV
+	for i := 0; i < 200; i++ {
+		kvs = append(kvs, newKV(fmt.Sprintf("k%03d", i), "the same long value, over and over again"))
+	}
+	out := encodeIMD(t, kvs, gzipCodec(t))
+	sorter := newExternalSorter(int64(len(out))*2, imdCodecs[0], byteOrder())
+	defer sorter.close()
+
+	rw := sorter.newRunWriter()
+	if _, err := rw.Write(out); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sorter.mem) != 0 || len(sorter.runs) != 1 {
+		t.Fatalf("the decoded pairs exceed the budget and should go to disk, got %d in memory and %d on disk", len(sorter.mem), len(sorter.runs))
+	}
+	it, err := sorter.iterator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for {
+		_, ok, err := it.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		n++
+	}
+	if n != len(kvs) {
+		t.Errorf("expected %d pairs back, got %d", len(kvs), n)
+	}
+}
@@ -1,42 +1,297 @@
 package worker
 
-import "strings"
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
 
-func encodeIMDKVs(kvs []KV) string {
-	if len(kvs) == 0 {
-		return ""
+// Intermediate files and runs are binary so that keys and values may hold any
+// bytes. A file starts with imdMagic, the format version and the id of its
+// codec, followed by blocks of records and an empty block that marks the end:
+//
+//	block  = uvarint(len(payload)) payload crc32(payload)
+//	record = uvarint(len(key)) key uvarint(len(value)) value
+//
+// The checksum is the big-endian IEEE CRC-32 of the payload. Everything after
+// the header goes through the codec.
+const (
+	imdMagic   = "MRIMD"
+	imdVersion = 1
+	// imdBlockSize is the payload size after which a block is closed.
+	imdBlockSize = 64 << 10
+	// imdMaxBlock bounds the block length a reader accepts, so a corrupt
+	// length cannot make it allocate without limit.
+	imdMaxBlock = 1 << 30
+)
+
+// errCorruptIMD is wrapped by every error about a malformed intermediate file.
+var errCorruptIMD = errors.New("corrupt intermediate data")
+
+// imdCodec compresses intermediate files. The name is what jobs ask for, the
+// id is what files record.
+type imdCodec struct {
+	name      string
+	id        byte
+	newWriter func(w io.Writer) io.WriteCloser
+	newReader func(r io.Reader) (io.Reader, error)
+}
+
+var imdCodecs = []imdCodec{
+	{
+		name:      "none",
+		id:        0,
+		newWriter: func(w io.Writer) io.WriteCloser { return nopWriteCloser{w} },
+		newReader: func(r io.Reader) (io.Reader, error) { return r, nil },
+	},
+	{
+		name:      "gzip",
+		id:        1,
+		newWriter: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		newReader: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	},
+	{
+		name: "flate",
+		id:   2,
+		newWriter: func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+		newReader: func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil },
+	},
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// codecByName returns the codec a task asks for; empty means none.
+func codecByName(name string) (imdCodec, error) {
+	if name == "" {
+		name = "none"
+	}
+	for _, c := range imdCodecs {
+		if c.name == name {
+			return c, nil
+		}
+	}
+	return imdCodec{}, fmt.Errorf("unknown compression %q", name)
+}
+
+func codecByID(id byte) (imdCodec, error) {
+	for _, c := range imdCodecs {
+		if c.id == id {
+			return c, nil
+		}
+	}
+	return imdCodec{}, fmt.Errorf("%w: unknown codec %v", errCorruptIMD, id)
+}
+
+// imdWriter encodes pairs in the intermediate format.
+type imdWriter struct {
+	w     *bufio.Writer
+	c     io.WriteCloser
+	block []byte
+}
+
+func newIMDWriter(w io.Writer, codec imdCodec) (*imdWriter, error) {
+	if _, err := w.Write(append([]byte(imdMagic), imdVersion, codec.id)); err != nil {
+		return nil, err
+	}
+	c := codec.newWriter(w)
+	return &imdWriter{w: bufio.NewWriter(c), c: c}, nil
+}
+
+func (w *imdWriter) write(kv KV) error {
+	w.block = appendString(w.block, kv.Key)
+	w.block = appendString(w.block, kv.Value)
+	if len(w.block) >= imdBlockSize {
+		return w.flushBlock()
+	}
+	return nil
+}
+
+func appendString(b []byte, s string) []byte {
+	var n [binary.MaxVarintLen64]byte
+	b = append(b, n[:binary.PutUvarint(n[:], uint64(len(s)))]...)
+	return append(b, s...)
+}
+
+func (w *imdWriter) flushBlock() error {
+	if len(w.block) == 0 {
+		return nil
+	}
+	w.writeUvarint(uint64(len(w.block)))
+	w.w.Write(w.block)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE(w.block))
+	_, err := w.w.Write(sum[:])
+	w.block = w.block[:0]
+	return err
+}
+
+func (w *imdWriter) writeUvarint(x uint64) {
+	var n [binary.MaxVarintLen64]byte
+	w.w.Write(n[:binary.PutUvarint(n[:], x)])
+}
+
+// close writes the last block and the end marker and flushes the output.
+func (w *imdWriter) close() error {
+	if err := w.flushBlock(); err != nil {
+		return err
+	}
+	w.writeUvarint(0)
+	if err := w.w.Flush(); err != nil {
+		return err
 	}
-	var b strings.Builder
-	// Rough pre-size to reduce reallocations for hot path.
-	b.Grow(len(kvs) * 24)
-	for i := range kvs {
-		b.WriteString(kvs[i].Key)
-		b.WriteByte('\t')
-		b.WriteString(kvs[i].Value)
-		b.WriteByte('\n')
+	return w.c.Close()
+}
+
+// imdReader decodes the intermediate format, verifying every block. A file
+// that ends before its end marker is reported as corrupt.
+type imdReader struct {
+	r          *bufio.Reader
+	header     bool
+	compressed bool
+	done       bool
+	blocks     int
+	block      []byte
+}
+
+func newIMDReader(r io.Reader) *imdReader {
+	return &imdReader{r: bufio.NewReader(r)}
+}
+
+func (r *imdReader) next() (KV, bool, error) {
+	for len(r.block) == 0 {
+		if r.done {
+			return KV{}, false, nil
+		}
+		if err := r.readBlock(); err != nil {
+			return KV{}, false, err
+		}
+	}
+	key, err := r.field()
+	if err != nil {
+		return KV{}, false, err
 	}
-	return b.String()
+	value, err := r.field()
+	if err != nil {
+		return KV{}, false, err
+	}
+	return KV{Key: key, Value: value}, true, nil
 }
 
-func decodeIMDKVs(raw string) []KV {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
+func (r *imdReader) field() (string, error) {
+	n, size := binary.Uvarint(r.block)
+	if size <= 0 || n > uint64(len(r.block)-size) {
+		return "", fmt.Errorf("%w: bad record in block %v", errCorruptIMD, r.blocks)
+	}
+	s := string(r.block[size : size+int(n)])
+	r.block = r.block[size+int(n):]
+	return s, nil
+}
+
+func (r *imdReader) readBlock() error {
+	if !r.header {
+		if err := r.readHeader(); err != nil {
+			return err
+		}
+		r.header = true
+	}
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return r.readErr(err)
+	}
+	if n == 0 {
+		r.done = true
+		if r.compressed {
+			// Read the codec trailer too, gzip checks its own checksum there.
+			if _, err := io.Copy(io.Discard, r.r); err != nil {
+				return r.readErr(err)
+			}
+		}
 		return nil
 	}
-	lines := strings.Split(raw, "\n")
-	out := make([]KV, 0, len(lines))
-	for _, line := range lines {
-		if line == "" {
-			continue
+	if n > imdMaxBlock {
+		return fmt.Errorf("%w: block %v of %v bytes", errCorruptIMD, r.blocks, n)
+	}
+	block := make([]byte, n+4)
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		return r.readErr(err)
+	}
+	if crc32.ChecksumIEEE(block[:n]) != binary.BigEndian.Uint32(block[n:]) {
+		return fmt.Errorf("%w: checksum mismatch in block %v", errCorruptIMD, r.blocks)
+	}
+	r.blocks++
+	r.block = block[:n]
+	return nil
+}
+
+func (r *imdReader) readHeader() error {
+	var h [len(imdMagic) + 1]byte
+	if _, err := io.ReadFull(r.r, h[:]); err != nil {
+		return r.readErr(err)
+	}
+	if string(h[:len(imdMagic)]) != imdMagic {
+		return fmt.Errorf("%w: not an intermediate file", errCorruptIMD)
+	}
+	if version := h[len(imdMagic)]; version != imdVersion {
+		return fmt.Errorf("%w: unsupported format version %v", errCorruptIMD, version)
+	}
+	id, err := r.r.ReadByte()
+	if err != nil {
+		return r.readErr(err)
+	}
+	codec, err := codecByID(id)
+	if err != nil {
+		return err
+	}
+	if codec.id == 0 {
+		return nil
+	}
+	dr, err := codec.newReader(r.r)
+	if err != nil {
+		return r.readErr(err)
+	}
+	r.r = bufio.NewReader(dr)
+	r.compressed = true
+	return nil
+}
+
+// readErr reports a file cut short or rejected by its codec as corrupt and
+// passes other errors on.
+func (r *imdReader) readErr(err error) error {
+	var flateErr flate.CorruptInputError
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return fmt.Errorf("%w: truncated after block %v", errCorruptIMD, r.blocks)
+	case err == gzip.ErrChecksum || err == gzip.ErrHeader || errors.As(err, &flateErr):
+		return fmt.Errorf("%w: %v", errCorruptIMD, err)
+	}
+	return err
+}
+
+// decodeIMDKVs decodes a whole intermediate file.
+func decodeIMDKVs(raw []byte) ([]KV, error) {
+	r := newIMDReader(bytes.NewReader(raw))
+	var out []KV
+	for {
+		kv, ok, err := r.next()
+		if err != nil {
+			return nil, err
 		}
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) != 2 {
-			continue
+		if !ok {
+			return out, nil
 		}
-		out = append(out, KV{
-			Key:   parts[0],
-			Value: parts[1],
-		})
+		out = append(out, kv)
 	}
-	return out
 }
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func encodeIMD(t *testing.T, kvs []KV, codec imdCodec) []byte {
+	var buf bytes.Buffer
+	w, err := newIMDWriter(&buf, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range kvs {
+		if err := w.write(kv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestIMDCodecKeepsArbitraryBytes(t *testing.T) {
+	kvs := []KV{
+		newKV("", ""),
+		newKV(" padded\t", "line one\nline two\n"),
+		newKV("tab\there", "  \r\n "),
+		newKV(string([]byte{0, 0xff, '\n'}), "binary"),
+	}
+	for i := 0; i < 5000; i++ {
+		kvs = append(kvs, newKV(fmt.Sprintf("key %05d\t", i), strconv.Itoa(i)))
+	}
+	for _, codec := range imdCodecs {
+		got, err := decodeIMDKVs(encodeIMD(t, kvs, codec))
+		if err != nil {
+			t.Fatalf("%v: %v", codec.name, err)
+		}
+		if len(got) != len(kvs) {
+			t.Fatalf("%v: expected %d pairs, got %d", codec.name, len(kvs), len(got))
+		}
+		for i := range kvs {
+			if got[i] != kvs[i] {
+				t.Fatalf("%v: pair %d: expected %q, got %q", codec.name, i, kvs[i], got[i])
+			}
+		}
+	}
+}
+
+func TestIMDCodecDetectsCorruption(t *testing.T) {
+	kvs := []KV{newKV("key", "value"), newKV("other", "value")}
+	raw := encodeIMD(t, kvs, imdCodecs[0])
+	flipped := append([]byte(nil), raw...)
+	flipped[10] ^= 1
+	gzipped := encodeIMD(t, kvs, gzipCodec(t))
+	cases := map[string][]byte{
+		"flipped bit":    flipped,
+		"truncated":      raw[:len(raw)-1],
+		"truncated gzip": gzipped[:len(gzipped)-10],
+		"old format":     []byte("key\tvalue\n"),
+	}
+	for name, data := range cases {
+		if _, err := decodeIMDKVs(data); !errors.Is(err, errCorruptIMD) {
+			t.Fatalf("%v: expected a corruption error, got %v", name, err)
+		}
+	}
+}
+
+func gzipCodec(t *testing.T) imdCodec {
+	codec, err := codecByName("gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codec
+}
@@ -0,0 +1,232 @@
+package worker
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+// MapRecordFormat is the optional MapRecord function of a plugin. It is called
+// once per input record, with the key and value the input format gives it.
+type MapRecordFormat (func(string, string, MrContext))
+
+// Input formats turn a split into records for MapRecord:
+//
+//	text   key = byte offset of the line, value = the line
+//	tsv    key = text before the first tab, value = the rest; blank lines are skipped
+//	csv    key = byte offset of the record, value = its fields as a JSON array
+//	jsonl  key = byte offset of the line, value = the JSON document; blank lines are skipped
+//	gzip   a gzip-compressed text file, offsets are in the decompressed text
+//	whole  one record per file, key = file name, value = the contents
+//
+// Lines lose their "\n" or "\r\n". The master cuts text, tsv and jsonl files
+// on line boundaries and csv files on record boundaries. It never cuts gzip
+// and whole files. An empty format is text.
+
+// recordReader reads the records of one split.
+type recordReader interface {
+	// next returns the next record, or false at the end of the split.
+	next() (key string, value string, ok bool, err error)
+}
+
+// openRecords opens the records of a split in the given format. The caller
+// closes the returned closer.
+func openRecords(format string, fInfo *rpc.MapFileInfo) (recordReader, io.Closer, error) {
+	switch format {
+	case "", "text", "tsv", "csv", "jsonl", "gzip", "whole":
+	default:
+		return nil, nil, fmt.Errorf("unknown input format %q", format)
+	}
+	f, err := os.Open(fInfo.FileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r io.Reader = io.NewSectionReader(f, fInfo.From, fInfo.To-fInfo.From)
+	base := fInfo.From
+	switch format {
+	case "gzip":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("%v: %v", fInfo.FileName, err)
+		}
+		r, base = gz, 0
+	}
+	return newRecordReader(format, fInfo.FileName, r, base), f, nil
+}
+
+// newRecordReader reads the records of format from r, which starts at byte
+// base of the file.
+func newRecordReader(format string, name string, r io.Reader, base int64) recordReader {
+	lines := &lineReader{r: bufio.NewReaderSize(r, 1<<20), offset: base, name: name}
+	switch format {
+	case "tsv":
+		return &tsvReader{lines}
+	case "csv":
+		return &csvReader{lines}
+	case "jsonl":
+		return &jsonlReader{lines}
+	case "whole":
+		return &wholeReader{r: r, name: name}
+	}
+	return &textReader{lines}
+}
+
+// lineReader reads lines and the offset they start at.
+type lineReader struct {
+	r      *bufio.Reader
+	offset int64
+	name   string
+}
+
+func (l *lineReader) line() (string, int64, bool, error) {
+	s, err := l.r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, false, err
+	}
+	if len(s) == 0 {
+		return "", 0, false, nil
+	}
+	at := l.offset
+	l.offset += int64(len(s))
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r"), at, true, nil
+}
+
+type textReader struct {
+	*lineReader
+}
+
+func (r *textReader) next() (string, string, bool, error) {
+	s, at, ok, err := r.line()
+	return strconv.FormatInt(at, 10), s, ok, err
+}
+
+type tsvReader struct {
+	*lineReader
+}
+
+func (r *tsvReader) next() (string, string, bool, error) {
+	for {
+		s, _, ok, err := r.line()
+		if !ok || err != nil {
+			return "", "", false, err
+		}
+		if s == "" {
+			continue
+		}
+		if i := strings.IndexByte(s, '\t'); i >= 0 {
+			return s[:i], s[i+1:], true, nil
+		}
+		return s, "", true, nil
+	}
+}
+
+type jsonlReader struct {
+	*lineReader
+}
+
+func (r *jsonlReader) next() (string, string, bool, error) {
+	for {
+		s, at, ok, err := r.line()
+		if !ok || err != nil {
+			return "", "", false, err
+		}
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		if !json.Valid([]byte(s)) {
+			return "", "", false, fmt.Errorf("%v offset %v: invalid JSON line", r.name, at)
+		}
+		return strconv.FormatInt(at, 10), s, true, nil
+	}
+}
+
+// csvReader reads RFC 4180 records, which may hold newlines inside quotes.
+type csvReader struct {
+	*lineReader
+}
+
+func (r *csvReader) next() (string, string, bool, error) {
+	s, at, ok, err := r.line()
+	if !ok || err != nil {
+		return "", "", false, err
+	}
+	// A record goes on while it has an odd number of quotes, escaped quotes
+	// come in pairs.
+	raw := s
+	for strings.Count(raw, `"`)%2 == 1 {
+		s, _, ok, err = r.line()
+		if err != nil {
+			return "", "", false, err
+		}
+		if !ok {
+			return "", "", false, fmt.Errorf("%v offset %v: unterminated quoted CSV field", r.name, at)
+		}
+		raw += "\n" + s
+	}
+	cr := csv.NewReader(strings.NewReader(raw))
+	cr.FieldsPerRecord = -1
+	fields, err := cr.Read()
+	if err == io.EOF {
+		// A blank line has no fields.
+		fields, err = []string{}, nil
+	}
+	if err != nil {
+		return "", "", false, fmt.Errorf("%v offset %v: %v", r.name, at, err)
+	}
+	value, _ := json.Marshal(fields)
+	return strconv.FormatInt(at, 10), string(value), true, nil
+}
+
+// CSVFields returns the fields of a record read by the csv input format.
+func CSVFields(value string) ([]string, error) {
+	var fields []string
+	err := json.Unmarshal([]byte(value), &fields)
+	return fields, err
+}
+
+type wholeReader struct {
+	r    io.Reader
+	name string
+	done bool
+}
+
+func (r *wholeReader) next() (string, string, bool, error) {
+	if r.done {
+		return "", "", false, nil
+	}
+	r.done = true
+	b, err := io.ReadAll(r.r)
+	if err != nil {
+		return "", "", false, err
+	}
+	return r.name, string(b), true, nil
+}
+
+// splitContent returns a split as one string for the Map function of a
+// plugin without MapRecord. Gzip files are decompressed.
+func splitContent(format string, fInfo *rpc.MapFileInfo) (string, error) {
+	if format != "gzip" {
+		return partialContent(fInfo)
+	}
+	f, err := os.Open(fInfo.FileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("%v: %v", fInfo.FileName, err)
+	}
+	b, err := io.ReadAll(gz)
+	return string(b), err
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+func TestInputFormatRecords(t *testing.T) {
+	cases := []struct {
+		format string
+		input  string
+		want   []KV
+	}{
+		{"text", "a b\r\n\nc", []KV{newKV("10", "a b"), newKV("15", ""), newKV("16", "c")}},
+		{"tsv", "k\tv\tw\n\nk2\n", []KV{newKV("k", "v\tw"), newKV("k2", "")}},
+		{"csv", "a,\"x,\n\"\"y\"\"\"\nb\n", []KV{newKV("10", `["a","x,\n\"y\""]`), newKV("23", `["b"]`)}},
+		{"jsonl", "{\"a\":1}\n\n[2]\n", []KV{newKV("10", `{"a":1}`), newKV("19", "[2]")}},
+		{"whole", "a\nb", []KV{newKV("in", "a\nb")}},
+	}
+	for _, c := range cases {
+		records := newRecordReader(c.format, "in", strings.NewReader(c.input), 10)
+		var got []KV
+		for {
+			key, value, ok, err := records.next()
+			if err != nil {
+				t.Fatalf("%v: %v", c.format, err)
+			}
+			if !ok {
+				break
+			}
+			got = append(got, newKV(key, value))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Fatalf("%v: expected %q, got %q", c.format, c.want, got)
+		}
+	}
+
+	for format, input := range map[string]string{"csv": "a,\"b\n", "jsonl": "{\n"} {
+		if _, _, _, err := newRecordReader(format, "in", strings.NewReader(input), 0).next(); err == nil {
+			t.Fatalf("%v: expected an error for %q", format, input)
+		}
+	}
+	if _, _, err := openRecords("xml", &rpc.MapFileInfo{FileName: "in"}); err == nil {
+		t.Fatal("expected an error for an unknown input format")
+	}
+}
@@ -3,6 +3,8 @@ package worker
 type KV struct {
 	Key   string `json:"k"`
 	Value string `json:"v"`
+	// output is the named output of a pair emitted with EmitTo.
+	output string
 }
 
 func newKV(k string, v string) KV {
@@ -11,9 +13,3 @@ func newKV(k string, v string) KV {
 		Value: v,
 	}
 }
-
-type byKey []KV
-
-func (a byKey) Len() int           { return len(a) }
-func (a byKey) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byKey) Less(i, j int) bool { return a[i].Key < a[j].Key }
@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+// HookFormat is the optional Setup or Cleanup function of a plugin.
+type HookFormat (func(*TaskInfo) error)
+
+// TaskInfo describes the task attempt a plugin function runs for. Setup is
+// called with it before the first record of the attempt and Cleanup after the
+// last one, on the worker running the attempt.
+type TaskInfo struct {
+	JobID string
+	// Kind is "map" or "reduce".
+	Kind string
+	// ID is the number of the task within its kind.
+	ID int
+	// Attempt counts the attempts of the task from 1, retries and backup
+	// copies included; 0 when the master did not say.
+	Attempt int
+	// Partition is the reducer of a reduce task, -1 for map tasks.
+	Partition int
+	// Sample is set on the attempts that only sample the map output keys of
+	// a range partitioned job.
+	Sample bool
+	// State is left to the plugin: what Setup stores here is seen by the
+	// functions of the attempt through MrContext.Task, and by Cleanup.
+	State interface{}
+	side  *sideInputs
+	// outputs receives what the attempt emits with EmitTo.
+	outputs *namedOutputs
+}
+
+func mapTaskInfo(in *rpc.MapInfo, attempt int64, side *sideInputs) *TaskInfo {
+	return &TaskInfo{JobID: in.JobId, Kind: "map", ID: int(in.Id), Attempt: int(attempt), Partition: -1,
+		Sample: in.SampleKeys > 0, side: side}
+}
+
+func reduceTaskInfo(in *rpc.ReduceInfo, attempt int64, side *sideInputs) *TaskInfo {
+	return &TaskInfo{JobID: in.JobId, Kind: "reduce", ID: int(in.Id), Attempt: int(attempt), Partition: int(in.Id), side: side}
+}
+
+// Task returns the attempt the function runs for, nil outside of pulled
+// tasks.
+func (mc *MrContext) Task() *TaskInfo {
+	return mc.task
+}
+
+// Param returns the job parameter key, or "" if the job has none.
+func (t *TaskInfo) Param(key string) string {
+	if t == nil || t.side == nil {
+		return ""
+	}
+	return t.side.params[key]
+}
+
+// CacheFile returns the path of the local copy of the job cache file with
+// base name name. The copy is shared by the tasks of the job on this worker
+// and must not be modified.
+func (t *TaskInfo) CacheFile(name string) (string, error) {
+	if t != nil && t.side != nil {
+		if path, ok := t.side.files[name]; ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("job has no cache file %v", name)
+}
+
+// withHooks runs an attempt between the Setup and Cleanup functions of the
+// plugin. Cleanup runs whenever Setup succeeded, and its error fails an
+// attempt that succeeded so far.
+func withHooks(funcs pluginFuncs, task *TaskInfo, run func() error) error {
+	if err := callHook("setup", funcs.setupf, task); err != nil {
+		return err
+	}
+	err := run()
+	if cerr := callHook("cleanup", funcs.cleanupf, task); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// callHook calls a Setup or Cleanup function, turning a panic into an error.
+func callHook(name string, hook HookFormat, task *TaskInfo) (err error) {
+	if hook == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v panic: %v", name, r)
+		}
+	}()
+	if err := hook(task); err != nil {
+		return fmt.Errorf("%v: %v", name, err)
+	}
+	return nil
+}
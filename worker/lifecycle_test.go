@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+func TestSetupCleanupHooks(t *testing.T) {
+	var calls []string
+	funcs := pluginFuncs{
+		setupf: func(task *TaskInfo) error {
+			calls = append(calls, "setup")
+			task.State = strings.Repeat("x", task.Attempt)
+			return nil
+		},
+		cleanupf: func(task *TaskInfo) error {
+			calls = append(calls, "cleanup")
+			if task.Param("fail") != "" {
+				return errors.New("flush failed")
+			}
+			return nil
+		},
+		reducef: func(key string, values []string, ctx MrContext) {
+			ctx.Emit(key, ctx.Task().State.(string))
+		},
+	}
+	task := reduceTaskInfo(&rpc.ReduceInfo{JobId: "job", Id: 3}, 2, &sideInputs{params: map[string]string{}})
+	var buf bytes.Buffer
+	err := withHooks(funcs, task, func() error {
+		_, err := callReduce(funcs.reducef, "k", nil, &buf, nil, task)
+		return err
+	})
+	if err != nil || buf.String() != "k xx\n" || task.Partition != 3 {
+		t.Fatalf("reduce should see the state set up for attempt 2, got %q, %v", buf.String(), err)
+	}
+
+	// Cleanup runs after a failed attempt too, but the attempt error wins.
+	task.side.params["fail"] = "1"
+	failed := errors.New("reduce failed")
+	if err := withHooks(funcs, task, func() error { return failed }); err != failed {
+		t.Errorf("expected the attempt error, got %v", err)
+	}
+	if err := withHooks(funcs, task, func() error { return nil }); err == nil || !strings.Contains(err.Error(), "flush failed") {
+		t.Errorf("a cleanup error should fail the attempt, got %v", err)
+	}
+	if len(calls) != 6 {
+		t.Errorf("expected setup and cleanup around each attempt, got %v", calls)
+	}
+
+	// Without a successful Setup, neither the attempt nor Cleanup runs.
+	calls = nil
+	funcs.setupf = func(*TaskInfo) error { panic("no model") }
+	ran := false
+	if err := withHooks(funcs, task, func() error { ran = true; return nil }); err == nil || ran || len(calls) != 0 {
+		t.Errorf("a setup panic should fail the attempt before it runs, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"fmt"
 	"net"
 	"os"
 	"plugin"
@@ -18,11 +19,24 @@ func Init(masterIP string) {
 	log.SetLevel(log.TraceLevel)
 }
 
-func StartWorker(pluginFile string, nReduce int, addr string, storeInRAM bool) {
+// StartWorker runs a worker at addr whose default job functions come from a
+// plugin file. It returns an error if the worker cannot start, or gives up on
+// the master.
+func StartWorker(pluginFile string, nReduce int, addr string, storeInRAM bool) error {
+	return startWorker(func() (pluginFuncs, error) { return openPlugin(pluginFile) }, nReduce, addr, storeInRAM)
+}
+
+// StartAppWorker runs a worker at addr whose default job functions are those
+// of an app registered with Register, so that no plugin is loaded.
+func StartAppWorker(app string, nReduce int, addr string, storeInRAM bool) error {
+	return startWorker(func() (pluginFuncs, error) { return lookupApp(app) }, nReduce, addr, storeInRAM)
+}
+
+func startWorker(load func() (pluginFuncs, error), nReduce int, addr string, storeInRAM bool) error {
 	// start gRPC server
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		log.Panic(err)
+		return err
 	}
 	wr := newWorker(nReduce, storeInRAM)
 	workerStruct := wr.(*Worker)
@@ -33,9 +47,18 @@ func StartWorker(pluginFile string, nReduce int, addr string, storeInRAM bool) {
 			log.Error(err)
 		}
 	}()
+	defer baseServer.Stop()
 	log.Info("Worker gRPC server start")
 
-	workerStruct.Mapf, workerStruct.Reducef = loadPlugin(pluginFile)
+	funcs, err := load()
+	if err != nil {
+		return err
+	}
+	workerStruct.Mapf, workerStruct.Reducef, workerStruct.Combinef = funcs.mapf, funcs.reducef, funcs.combinef
+	workerStruct.MapRecordf = funcs.maprecordf
+	workerStruct.Partitionf = funcs.partitionf
+	workerStruct.Comparef, workerStruct.GroupComparef = funcs.comparef, funcs.groupf
+	workerStruct.Setupf, workerStruct.Cleanupf = funcs.setupf, funcs.cleanupf
 	log.Info("Worker load plugin finish")
 
 	// Register itself
@@ -44,40 +67,172 @@ func StartWorker(pluginFile string, nReduce int, addr string, storeInRAM bool) {
 		Ip:   addr,
 	})
 	if err != nil {
-		log.Panic(err)
+		return err
 	}
 	workerStruct.setID(id)
 	log.Info("Worker register itself finish")
 
+	pulled := make(chan error, 1)
+	go func() { pulled <- workerStruct.pullTasks() }()
+
 	defer workerStruct.Client.(*masterClient).conn.Close()
 
-	<-workerStruct.EndChan
+	select {
+	case <-workerStruct.EndChan:
+	case err := <-pulled:
+		if err != nil {
+			// Nobody is left to give this worker tasks or to end it.
+			os.RemoveAll(workerStruct.cacheDir())
+			return fmt.Errorf("give up on the master: %w", err)
+		}
+		<-workerStruct.EndChan
+	}
+	os.RemoveAll(workerStruct.cacheDir())
 
 	// Sleep for a while for waiting the End Grpc response sent to master
 	time.Sleep(500 * time.Millisecond)
-	baseServer.Stop()
+	return nil
+}
+
+type pluginFuncs struct {
+	mapf    MapFormat
+	reducef ReduceFormat
+	// maprecordf is the optional MapRecord function, used instead of mapf
+	// when the plugin has one.
+	maprecordf MapRecordFormat
+	// combinef is the optional Combine function, nil if the plugin has none.
+	combinef ReduceFormat
+	// partitionf is the optional Partition function.
+	partitionf PartitionFormat
+	// comparef and groupf are the optional Compare and GroupCompare
+	// functions.
+	comparef CompareFormat
+	groupf   CompareFormat
+	// setupf and cleanupf are the optional Setup and Cleanup functions.
+	setupf   HookFormat
+	cleanupf HookFormat
+}
+
+// defaultFuncs returns the functions of the plugin the worker was started
+// with.
+func (wr *Worker) defaultFuncs() pluginFuncs {
+	return pluginFuncs{
+		mapf:       wr.Mapf,
+		maprecordf: wr.MapRecordf,
+		reducef:    wr.Reducef,
+		combinef:   wr.Combinef,
+		partitionf: wr.Partitionf,
+		comparef:   wr.Comparef,
+		groupf:     wr.GroupComparef,
+		setupf:     wr.Setupf,
+		cleanupf:   wr.Cleanupf,
+	}
+}
+
+// taskFuncs returns the functions of the job of a task: its registered app,
+// or its plugin.
+func (wr *Worker) taskFuncs(task *rpc.Task) (pluginFuncs, error) {
+	if task.App != "" {
+		return lookupApp(task.App)
+	}
+	return wr.pluginFuncs(task.Plugin)
 }
 
-// load the application Map and Reduce functions
-// from a plugin file, e.g. .so files
-func loadPlugin(filename string) (func(string, string, MrContext), func(string, []string, MrContext)) {
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		log.Panic(err)
+// pluginFuncs returns the functions of a job plugin, loading it on first use.
+// An empty file stands for the plugin the worker was started with.
+func (wr *Worker) pluginFuncs(file string) (pluginFuncs, error) {
+	if file == "" {
+		return wr.defaultFuncs(), nil
+	}
+	wr.mux.Lock()
+	defer wr.mux.Unlock()
+	if funcs, ok := wr.plugins[file]; ok {
+		return funcs, nil
+	}
+	funcs, err := openPlugin(file)
+	if err != nil {
+		return pluginFuncs{}, err
+	}
+	wr.plugins[file] = funcs
+	log.Info("Worker load plugin ", file)
+	return wr.plugins[file], nil
+}
+
+// openPlugin looks up Map or MapRecord, which takes one input record at a
+// time, Reduce, the optional Combine function, which has the signature of
+// Reduce, and the optional Partition, Compare and GroupCompare functions.
+func openPlugin(filename string) (pluginFuncs, error) {
+	if _, err := os.Stat(filename); err != nil {
+		return pluginFuncs{}, err
 	}
 	p, err := plugin.Open(filename)
 	if err != nil {
-		log.Panic(err)
+		return pluginFuncs{}, err
+	}
+	var funcs pluginFuncs
+	if xmaprecordf, err := p.Lookup("MapRecord"); err == nil {
+		maprecordf, ok := xmaprecordf.(func(string, string, MrContext))
+		if !ok {
+			return pluginFuncs{}, fmt.Errorf("plugin %v: MapRecord has type %T", filename, xmaprecordf)
+		}
+		funcs.maprecordf = maprecordf
 	}
 	xmapf, err := p.Lookup("Map")
-	if err != nil {
-		log.Panic(err)
+	if err != nil && funcs.maprecordf == nil {
+		return pluginFuncs{}, err
+	}
+	if err == nil {
+		mapf, ok := xmapf.(func(string, string, MrContext))
+		if !ok {
+			return pluginFuncs{}, fmt.Errorf("plugin %v: Map has type %T", filename, xmapf)
+		}
+		funcs.mapf = mapf
 	}
-	mapf := xmapf.(func(string, string, MrContext))
 	xreducef, err := p.Lookup("Reduce")
 	if err != nil {
-		log.Panic(err)
+		return pluginFuncs{}, err
 	}
-	reducef := xreducef.(func(string, []string, MrContext))
+	reducef, ok := xreducef.(func(string, []string, MrContext))
+	if !ok {
+		return pluginFuncs{}, fmt.Errorf("plugin %v: Reduce has type %T", filename, xreducef)
+	}
+	funcs.reducef = reducef
 
-	return mapf, reducef
+	if xcombinef, err := p.Lookup("Combine"); err == nil {
+		combinef, ok := xcombinef.(func(string, []string, MrContext))
+		if !ok {
+			return pluginFuncs{}, fmt.Errorf("plugin %v: Combine has type %T", filename, xcombinef)
+		}
+		funcs.combinef = combinef
+	}
+	if xpartitionf, err := p.Lookup("Partition"); err == nil {
+		partitionf, ok := xpartitionf.(func(string, int) int)
+		if !ok {
+			return pluginFuncs{}, fmt.Errorf("plugin %v: Partition has type %T", filename, xpartitionf)
+		}
+		funcs.partitionf = partitionf
+	}
+	for name, f := range map[string]*CompareFormat{"Compare": &funcs.comparef, "GroupCompare": &funcs.groupf} {
+		x, err := p.Lookup(name)
+		if err != nil {
+			continue
+		}
+		comparef, ok := x.(func(string, string) int)
+		if !ok {
+			return pluginFuncs{}, fmt.Errorf("plugin %v: %v has type %T", filename, name, x)
+		}
+		*f = comparef
+	}
+	for name, f := range map[string]*HookFormat{"Setup": &funcs.setupf, "Cleanup": &funcs.cleanupf} {
+		x, err := p.Lookup(name)
+		if err != nil {
+			continue
+		}
+		hook, ok := x.(func(*TaskInfo) error)
+		if !ok {
+			return pluginFuncs{}, fmt.Errorf("plugin %v: %v has type %T", filename, name, x)
+		}
+		*f = hook
+	}
+	return funcs, nil
 }
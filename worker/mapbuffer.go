@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// mapOutputBuffer collects the pairs a map task emits within a memory budget.
+// Once the budget is reached the pairs are sorted by partition and key and
+// spilled to disk, one run per partition; iterators merges the spills back
+// into one sorted stream per partition. The plugin combiner, if any, runs on
+// every spill and again on the merged streams.
+type mapOutputBuffer struct {
+	budget   int64
+	combinef ReduceFormat
+	codec    imdCodec
+	order    *keyOrder
+	dir      string
+	buf      []partKV
+	size     int64
+	// runs holds the spill files of each partition.
+	runs [][]string
+	open []*os.File
+}
+
+type partKV struct {
+	part int
+	kv   KV
+}
+
+func newMapOutputBuffer(nReduce int, budget int64, combinef ReduceFormat, codec imdCodec, order *keyOrder) *mapOutputBuffer {
+	return &mapOutputBuffer{budget: budget, combinef: combinef, codec: codec, order: order, runs: make([][]string, nReduce)}
+}
+
+// mapBufferBudget is the memory a map task may use to buffer its output,
+// MR_MAP_BUFFER_MB megabytes.
+func mapBufferBudget() int64 {
+	return int64(intFromEnv("MR_MAP_BUFFER_MB", 100)) << 20
+}
+
+func (b *mapOutputBuffer) add(part int, kv KV) error {
+	b.buf = append(b.buf, partKV{part: part, kv: kv})
+	b.size += int64(len(kv.Key)+len(kv.Value)) + kvOverhead
+	if b.size >= b.budget {
+		return b.spill()
+	}
+	return nil
+}
+
+// sort orders the buffer by partition, then key.
+func (b *mapOutputBuffer) sort() {
+	sort.Slice(b.buf, func(i, j int) bool {
+		if b.buf[i].part != b.buf[j].part {
+			return b.buf[i].part < b.buf[j].part
+		}
+		return b.order.less(b.buf[i].kv.Key, b.buf[j].kv.Key)
+	})
+}
+
+// spill sorts the buffer and writes each partition of it as one run.
+func (b *mapOutputBuffer) spill() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	if b.dir == "" {
+		dir, err := os.MkdirTemp("", "mr-map-")
+		if err != nil {
+			return err
+		}
+		b.dir = dir
+	}
+	b.sort()
+	for part, kvs := range b.partitions() {
+		if len(kvs) == 0 {
+			continue
+		}
+		name := filepath.Join(b.dir, fmt.Sprintf("spill-%v-%v", part, len(b.runs[part])))
+		if _, err := writeRun(name, withCombiner(&sliceIterator{kvs: kvs}, b.combinef), b.codec); err != nil {
+			return err
+		}
+		b.runs[part] = append(b.runs[part], name)
+	}
+	b.buf = nil
+	b.size = 0
+	return nil
+}
+
+// partitions splits the sorted buffer into the pairs of each partition.
+func (b *mapOutputBuffer) partitions() [][]KV {
+	ret := make([][]KV, len(b.runs))
+	for _, p := range b.buf {
+		ret[p.part] = append(ret[p.part], p.kv)
+	}
+	return ret
+}
+
+// spills returns the number of spills so far.
+func (b *mapOutputBuffer) spills() int {
+	n := 0
+	for _, runs := range b.runs {
+		if len(runs) > n {
+			n = len(runs)
+		}
+	}
+	return n
+}
+
+// iterators returns the pairs of each partition in key order, merging what
+// is still buffered with the spills.
+func (b *mapOutputBuffer) iterators() ([]kvIterator, error) {
+	b.sort()
+	ret := make([]kvIterator, len(b.runs))
+	for part, kvs := range b.partitions() {
+		its := []kvIterator{&sliceIterator{kvs: kvs}}
+		for _, name := range b.runs[part] {
+			f, err := os.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			b.open = append(b.open, f)
+			its = append(its, newIMDReader(f))
+		}
+		it, err := newMergeIterator(its, b.order)
+		if err != nil {
+			return nil, err
+		}
+		ret[part] = withCombiner(it, b.combinef)
+	}
+	return ret, nil
+}
+
+// close removes the spills.
+func (b *mapOutputBuffer) close() {
+	for _, f := range b.open {
+		f.Close()
+	}
+	if b.dir != "" {
+		os.RemoveAll(b.dir)
+	}
+}
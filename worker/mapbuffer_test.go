@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestMapOutputBufferSpillsSortedPartitions(t *testing.T) {
+	const nReduce = 3
+	buffer := newMapOutputBuffer(nReduce, 512, nil, gzipCodec(t), byteOrder())
+	defer buffer.close()
+	want := make([]int, nReduce)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("k%03d", (i*37)%100)
+		part := reducerForKey(key, nReduce)
+		want[part]++
+		if err := buffer.add(part, newKV(key, "1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buffer.spills() == 0 {
+		t.Fatal("expected the buffer to spill past its budget")
+	}
+
+	its, err := buffer.iterators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for part, it := range its {
+		count := 0
+		prev := ""
+		for {
+			kv, ok, err := it.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				break
+			}
+			if kv.Key < prev || reducerForKey(kv.Key, nReduce) != part {
+				t.Fatalf("partition %d: unexpected key %q after %q", part, kv.Key, prev)
+			}
+			prev = kv.Key
+			count++
+		}
+		if count != want[part] {
+			t.Fatalf("partition %d: expected %d pairs, got %d", part, want[part], count)
+		}
+	}
+}
+
+func TestMapOutputBufferCombines(t *testing.T) {
+	sum := func(key string, values []string, ctx MrContext) {
+		total := 0
+		for _, v := range values {
+			n, _ := strconv.Atoi(v)
+			total += n
+		}
+		ctx.Emit(key, strconv.Itoa(total))
+	}
+	buffer := newMapOutputBuffer(1, 256, sum, imdCodecs[0], byteOrder())
+	defer buffer.close()
+	for i := 0; i < 100; i++ {
+		if err := buffer.add(0, newKV(fmt.Sprintf("k%d", i%4), "1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buffer.spills() == 0 {
+		t.Fatal("expected the buffer to spill past its budget")
+	}
+
+	its, err := buffer.iterators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []KV
+	for {
+		kv, ok, err := its[0].next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		got = append(got, kv)
+	}
+	want := []KV{newKV("k0", "25"), newKV("k1", "25"), newKV("k2", "25"), newKV("k3", "25")}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
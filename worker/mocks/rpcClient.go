@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"io"
+
 	"github.com/emptyOVO/mrkit-go/rpc"
 	"google.golang.org/grpc"
 )
@@ -30,6 +32,27 @@ func (client *MasterClient) UpdateIMDInfo(u *rpc.IMDInfo) bool {
 	return Result.(bool)
 }
 
-func (client *MasterClient) GetIMDData(ip string, filename string) []rpc.KV {
-	return Result.([]rpc.KV)
+func (client *MasterClient) FetchIMD(ip string, filename string, w io.Writer) error {
+	_, err := io.WriteString(w, Result.(string))
+	return err
+}
+
+func (client *MasterClient) FetchCacheFile(jobID string, name string, w io.Writer) error {
+	_, err := io.WriteString(w, Result.(string))
+	return err
+}
+
+func (client *MasterClient) RequestTask(w *rpc.WorkerInfo) (*rpc.Task, error) {
+	Request = w
+	return Result.(*rpc.Task), nil
+}
+
+func (client *MasterClient) ReportTask(r *rpc.TaskResult) bool {
+	Request = r
+	return Result.(bool)
+}
+
+func (client *MasterClient) ReportFetchFailure(f *rpc.FetchFailure) bool {
+	Request = f
+	return Result.(bool)
 }
@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// outputNameRe is the syntax of named outputs. "out" is the main output.
+var outputNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EmitTo writes a pair to the named output name instead of the main output,
+// from Map, MapRecord or Reduce. The outputs of the winning attempt of a task
+// are committed next to mr-out-<reducer>.txt, as mr-<name>-m-<task>.txt for
+// map tasks and mr-<name>-r-<reducer>.txt for reduce tasks, one "key value"
+// line per pair.
+func (mc *MrContext) EmitTo(name string, key string, value string) {
+	kv := newKV(key, value)
+	kv.output = name
+	mc.Chan <- kv
+}
+
+// NamedOutputGlob matches the committed files of the named output name.
+func NamedOutputGlob(dir string, name string) string {
+	return filepath.Join(dir, fmt.Sprintf("mr-%v-*.txt", name))
+}
+
+// namedOutputs holds the named outputs of one attempt in temporary files
+// until they are committed or discarded along with the attempt.
+type namedOutputs struct {
+	dir    string
+	files  map[string]*os.File
+	bufs   map[string]*bufio.Writer
+	closed bool
+}
+
+func newNamedOutputs(dir string) *namedOutputs {
+	if dir == "" {
+		dir = "."
+	}
+	return &namedOutputs{dir: dir, files: make(map[string]*os.File), bufs: make(map[string]*bufio.Writer)}
+}
+
+// write appends a pair to its output, creating the output on first use.
+func (o *namedOutputs) write(kv KV) error {
+	if o == nil {
+		return fmt.Errorf("named output %v is not available here", kv.output)
+	}
+	w, ok := o.bufs[kv.output]
+	if !ok {
+		if kv.output == "out" || !outputNameRe.MatchString(kv.output) {
+			return fmt.Errorf("invalid output name %q", kv.output)
+		}
+		if err := os.MkdirAll(o.dir, 0o755); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(o.dir, fmt.Sprintf(".mr-%v-*", kv.output))
+		if err != nil {
+			return err
+		}
+		w = bufio.NewWriter(f)
+		o.files[kv.output], o.bufs[kv.output] = f, w
+	}
+	_, err := fmt.Fprintf(w, "%v %v\n", kv.Key, kv.Value)
+	return err
+}
+
+// close flushes the outputs to their temporary files.
+func (o *namedOutputs) close() error {
+	if o == nil || o.closed {
+		return nil
+	}
+	o.closed = true
+	var first error
+	for name, f := range o.files {
+		if err := o.bufs[name].Flush(); err != nil && first == nil {
+			first = err
+		}
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// commit renames the outputs to mr-<name>-<suffix>.txt.
+func (o *namedOutputs) commit(suffix string) error {
+	if o == nil {
+		return nil
+	}
+	for _, name := range o.names() {
+		if err := os.Rename(o.files[name].Name(), filepath.Join(o.dir, fmt.Sprintf("mr-%v-%v.txt", name, suffix))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discard removes the temporary files of the outputs.
+func (o *namedOutputs) discard() {
+	if o == nil {
+		return
+	}
+	o.close()
+	for _, f := range o.files {
+		os.Remove(f.Name())
+	}
+}
+
+func (o *namedOutputs) names() []string {
+	names := make([]string, 0, len(o.files))
+	for name := range o.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
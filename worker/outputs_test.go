@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+func TestNamedOutputs(t *testing.T) {
+	dir := t.TempDir()
+	task := reduceTaskInfo(&rpc.ReduceInfo{JobId: "job", Id: 2}, 1, nil)
+	task.outputs = newNamedOutputs(dir)
+	reducef := func(key string, values []string, ctx MrContext) {
+		for _, v := range values {
+			if v == "bad" {
+				ctx.EmitTo("errors", key, v)
+				continue
+			}
+			ctx.EmitTo("daily", key, v)
+		}
+		ctx.Emit(key, strconv.Itoa(len(values)))
+	}
+	var buf bytes.Buffer
+	if _, err := callReduce(reducef, "k", []string{"a", "bad", "b"}, &buf, nil, task); err != nil || buf.String() != "k 3\n" {
+		t.Fatalf("main output should only hold Emit, got %q, %v", buf.String(), err)
+	}
+	if err := task.outputs.close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := task.outputs.commit("r-2"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"daily": "k a\nk b\n", "errors": "k bad\n"} {
+		got, err := os.ReadFile(filepath.Join(dir, "mr-"+name+"-r-2.txt"))
+		if err != nil || string(got) != want {
+			t.Errorf("output %v: got %q, %v", name, got, err)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, ".mr-*")); len(files) != 0 {
+		t.Errorf("temporary files left after commit: %v", files)
+	}
+
+	// "out" names the main output and cannot be used with EmitTo.
+	task.outputs = newNamedOutputs(dir)
+	_, err := callReduce(func(key string, values []string, ctx MrContext) { ctx.EmitTo("out", key, "") }, "k", nil, &buf, nil, task)
+	if err == nil {
+		t.Error("EmitTo(\"out\") should fail the reduce")
+	}
+	task.outputs.discard()
+	if files, _ := filepath.Glob(filepath.Join(dir, ".mr-*")); len(files) != 0 {
+		t.Errorf("temporary files left after discard: %v", files)
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+// PartitionFormat is the optional Partition function of a plugin. It returns
+// the reducer of a key, in [0, nReduce).
+type PartitionFormat (func(string, int) int)
+
+// partitioner returns the function placing the keys of a map task: by the
+// split points of a range partitioned job, by the Partition function of the
+// plugin, or by a hash of the key.
+func partitioner(in *rpc.MapInfo, partitionf PartitionFormat, nReduce int) func(key string) (int, error) {
+	switch {
+	case in.Partitioner == "range":
+		points := in.SplitPoints
+		return func(key string) (int, error) { return rangePartition(points, key), nil }
+	case partitionf != nil:
+		return func(key string) (int, error) { return callPartition(partitionf, key, nReduce) }
+	}
+	return func(key string) (int, error) { return reducerForKey(key, nReduce), nil }
+}
+
+// rangePartition returns the number of split points not above key, so that
+// reducer i gets the keys in [points[i-1], points[i]). Keys compare byte-wise,
+// as when the master picked the points; sample attempts report a plugin
+// Compare function so that the master fails such a job instead.
+func rangePartition(points []string, key string) int {
+	return sort.Search(len(points), func(i int) bool { return points[i] > key })
+}
+
+// callPartition runs partitionf on one key, turning a panic or a reducer out
+// of range into an error.
+func callPartition(partitionf PartitionFormat, key string, nReduce int) (part int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("partition %v panic: %v", key, r)
+		}
+	}()
+	part = partitionf(key, nReduce)
+	if part < 0 || part >= nReduce {
+		return 0, fmt.Errorf("partition %v returned reducer %v of %v", key, part, nReduce)
+	}
+	return part, nil
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"fmt"
+	"sync"
+)
+
+// App is the job logic of a program that links it in instead of building a
+// plugin. The fields are the functions a plugin exports under the same names;
+// Reduce and one of Map and MapRecord are required.
+type App struct {
+	Map          MapFormat
+	MapRecord    MapRecordFormat
+	Reduce       ReduceFormat
+	Combine      ReduceFormat
+	Partition    PartitionFormat
+	Compare      CompareFormat
+	GroupCompare CompareFormat
+	Setup        HookFormat
+	Cleanup      HookFormat
+}
+
+var (
+	appsMux sync.Mutex
+	apps    = make(map[string]pluginFuncs)
+)
+
+// Register makes an app available under name to the workers of this process,
+// for jobs that set JobSpec.App and for StartAppWorker. It is meant to be
+// called from init functions and panics if the name is taken or the app has
+// no map or reduce function.
+func Register(name string, app App) {
+	if name == "" {
+		panic("worker: Register with an empty app name")
+	}
+	if (app.Map == nil && app.MapRecord == nil) || app.Reduce == nil {
+		panic(fmt.Sprintf("worker: app %v needs Reduce and Map or MapRecord", name))
+	}
+	appsMux.Lock()
+	defer appsMux.Unlock()
+	if _, ok := apps[name]; ok {
+		panic(fmt.Sprintf("worker: app %v registered twice", name))
+	}
+	apps[name] = pluginFuncs{
+		mapf:       app.Map,
+		maprecordf: app.MapRecord,
+		reducef:    app.Reduce,
+		combinef:   app.Combine,
+		partitionf: app.Partition,
+		comparef:   app.Compare,
+		groupf:     app.GroupCompare,
+		setupf:     app.Setup,
+		cleanupf:   app.Cleanup,
+	}
+}
+
+func lookupApp(name string) (pluginFuncs, error) {
+	appsMux.Lock()
+	defer appsMux.Unlock()
+	funcs, ok := apps[name]
+	if !ok {
+		return pluginFuncs{}, fmt.Errorf("app %v is not registered in this worker", name)
+	}
+	return funcs, nil
+}
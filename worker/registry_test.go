@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+// registerTestApp registers app under name for the duration of the test.
+func registerTestApp(t *testing.T, name string, app App) {
+	Register(name, app)
+	t.Cleanup(func() {
+		appsMux.Lock()
+		defer appsMux.Unlock()
+		delete(apps, name)
+	})
+}
+
+func TestRegisteredApp(t *testing.T) {
+	registerTestApp(t, "test-app", App{
+		MapRecord: func(key string, value string, ctx MrContext) { ctx.EmitIntermediate(value, "1") },
+		Reduce:    func(key string, values []string, ctx MrContext) { ctx.Emit(key, strconv.Itoa(len(values))) },
+	})
+	wr := &Worker{plugins: make(map[string]pluginFuncs)}
+	funcs, err := wr.taskFuncs(&rpc.Task{App: "test-app", Plugin: "ignored.so"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if funcs.maprecordf == nil || funcs.reducef == nil || funcs.mapf != nil {
+		t.Fatal("task should get the functions of its app")
+	}
+	if _, err := wr.taskFuncs(&rpc.Task{App: "missing-app"}); err == nil {
+		t.Fatal("expected an error for an app that is not registered")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering an app twice should panic")
+		}
+	}()
+	Register("test-app", App{Map: func(string, string, MrContext) {}, Reduce: funcs.reducef})
+}
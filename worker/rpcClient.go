@@ -3,6 +3,9 @@ package worker
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/emptyOVO/mrkit-go/rpc"
@@ -16,7 +19,27 @@ type RpcClient interface {
 	//Connect()
 	WorkerRegister(w *rpc.WorkerInfo) (int, error)
 	UpdateIMDInfo(u *rpc.IMDInfo) bool
-	GetIMDData(ip string, filename string) []KV
+	FetchIMD(ip string, filename string, w io.Writer) error
+	FetchCacheFile(jobID string, name string, w io.Writer) error
+	RequestTask(w *rpc.WorkerInfo) (*rpc.Task, error)
+	ReportTask(r *rpc.TaskResult) bool
+	ReportFetchFailure(f *rpc.FetchFailure) bool
+}
+
+// fetchError means an intermediate file could not be read from the worker
+// holding it, typically because that worker is gone.
+type fetchError struct {
+	IP       string
+	Filename string
+	err      error
+}
+
+func (e *fetchError) Error() string {
+	return fmt.Sprintf("fetch %v from %v: %v", e.Filename, e.IP, e.err)
+}
+
+func (e *fetchError) Unwrap() error {
+	return e.err
 }
 
 type masterClient struct {
@@ -24,6 +47,30 @@ type masterClient struct {
 	conn   *grpc.ClientConn
 }
 
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	secs, err := strconv.Atoi(raw)
+	if err != nil || secs <= 0 {
+		return def
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func intFromEnv(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
 func Connect(ip string) (*grpc.ClientConn, rpc.MasterClient) {
 	conn, err := grpc.Dial(ip, grpc.WithInsecure())
 	if err != nil {
@@ -94,27 +141,181 @@ func (client *masterClient) UpdateIMDInfo(u *rpc.IMDInfo) bool {
 	return r.Result
 }
 
-func (client *masterClient) GetIMDData(ip string, filename string) []KV {
-	conn, _ := Connect(ip)
-
-	c := rpc.NewWorkerClient(conn)
+// callMaster runs call until it succeeds, fails for a reason other than the
+// master being unreachable, or the master stays unreachable for longer than
+// MR_MASTER_RETRY_SEC. The latter covers a master restarting with --resume.
+func callMaster(call func(ctx context.Context) error) error {
+	const backoff = 200 * time.Millisecond
+	deadline := time.Now().Add(durationFromEnv("MR_MASTER_RETRY_SEC", 60*time.Second))
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := call(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		code := status.Code(err)
+		if (code != codes.Unavailable && code != codes.DeadlineExceeded) || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(backoff)
+	}
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
+func (client *masterClient) RequestTask(w *rpc.WorkerInfo) (*rpc.Task, error) {
+	var r *rpc.Task
+	err := callMaster(func(ctx context.Context) error {
+		var err error
+		r, err = client.master.RequestTask(ctx, w)
+		return err
+	})
+	if err != nil {
+		if respErr, ok := status.FromError(err); ok {
+			return nil, fmt.Errorf("request task rpc failed: %s", respErr.Message())
+		}
+		return nil, err
+	}
+	return r, nil
+}
 
-	r, err := c.GetIMDData(ctx, &rpc.IMDLoc{
-		Filename: filename,
+func (client *masterClient) ReportTask(r *rpc.TaskResult) bool {
+	var res *rpc.UpdateResult
+	err := callMaster(func(ctx context.Context) error {
+		var err error
+		res, err = client.master.ReportTask(ctx, r)
+		return err
 	})
 	if err != nil {
 		respErr, ok := status.FromError(err)
 		if ok {
-			//actual error from gRPC
-			//todo: we can improve error handling by using grpc statusCodes()
-			log.Panic(respErr.Message())
+			log.Warn("[Worker] Report task failed: " + respErr.Message())
 		} else {
-			log.Panic(err)
+			log.Warn("[Worker] Report task failed: " + err.Error())
+		}
+		return false
+	}
+	return res.Result
+}
+
+// FetchIMD streams an intermediate file from the worker at ip into w. A
+// broken stream is resumed at the last byte received; the fetch gives up with
+// a *fetchError after three attempts in a row that make no progress, or when
+// no chunk arrives within MR_FETCH_TIMEOUT_SEC seconds (default 10). An error
+// writing to w is returned as is.
+func (client *masterClient) FetchIMD(ip string, filename string, w io.Writer) error {
+	conn, _ := Connect(ip)
+	defer conn.Close()
+
+	c := rpc.NewWorkerClient(conn)
+	cw := &countingWriter{w: w}
+	err := fetchResuming(func(ctx context.Context, offset int64) (chunkStream, error) {
+		return c.FetchIMD(ctx, &rpc.IMDLoc{Filename: filename, Offset: offset})
+	}, cw, fmt.Sprintf("%v from %v", filename, ip))
+	if err != nil && cw.err == nil {
+		return &fetchError{IP: ip, Filename: filename, err: err}
+	}
+	return err
+}
+
+// FetchCacheFile streams a cache file of a job from the master into w, and
+// resumes broken streams like FetchIMD.
+func (client *masterClient) FetchCacheFile(jobID string, name string, w io.Writer) error {
+	return fetchResuming(func(ctx context.Context, offset int64) (chunkStream, error) {
+		return client.master.FetchCacheFile(ctx, &rpc.CacheFileLoc{JobId: jobID, Name: name, Offset: offset})
+	}, &countingWriter{w: w}, "cache file "+name)
+}
+
+// chunkStream is the client side of a FetchIMD or FetchCacheFile stream.
+type chunkStream interface {
+	Recv() (*rpc.IMDChunk, error)
+}
+
+// fetchResuming copies the streams open returns into cw, opening a new one at
+// the last byte received while the previous one broke. It gives up after
+// three attempts in a row that make no progress, and returns the error of the
+// last one, or the first error writing to cw.
+func fetchResuming(open func(ctx context.Context, offset int64) (chunkStream, error), cw *countingWriter, what string) error {
+	const maxAttempts = 3
+	idle := durationFromEnv("MR_FETCH_TIMEOUT_SEC", 10*time.Second)
+
+	var lastErr error
+	for failures := 0; failures < maxAttempts; {
+		offset := cw.n
+		err := fetchFrom(open, offset, idle, cw)
+		if err == nil {
+			return nil
+		}
+		if cw.err != nil {
+			return cw.err
+		}
+		lastErr = err
+		if cw.n > offset {
+			failures = 0
+		} else {
+			failures++
+		}
+		code := status.Code(err)
+		if code != codes.Unavailable && code != codes.DeadlineExceeded && code != codes.Canceled {
+			break
+		}
+		log.Trace(fmt.Sprintf("[Worker] Resume fetching %v at byte %v: %v", what, cw.n, err))
+	}
+	return lastErr
+}
+
+// fetchFrom runs one stream from offset into w. The stream is canceled once
+// no chunk has arrived for idle.
+func fetchFrom(open func(ctx context.Context, offset int64) (chunkStream, error), offset int64, idle time.Duration, w io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	timer := time.AfterFunc(idle, cancel)
+	defer timer.Stop()
+
+	stream, err := open(ctx, offset)
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		timer.Reset(idle)
+		if _, err := w.Write(chunk.Data); err != nil {
+			return err
 		}
 	}
+}
 
-	return decodeIMDKVs(r.Kvs)
+// countingWriter counts the bytes written to w and keeps the first error.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	if err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return n, err
+}
+
+func (client *masterClient) ReportFetchFailure(f *rpc.FetchFailure) bool {
+	var res *rpc.UpdateResult
+	err := callMaster(func(ctx context.Context) error {
+		var err error
+		res, err = client.master.ReportFetchFailure(ctx, f)
+		return err
+	})
+	if err != nil {
+		log.Warn("[Worker] Report fetch failure failed: ", err)
+		return false
+	}
+	return res.Result
 }
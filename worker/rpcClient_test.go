@@ -0,0 +1,205 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// streamPlan scripts one stream of a fetch: it sends up to bytes bytes from
+// the offset it was opened at (all of them if bytes < 0), then ends with err,
+// or with io.EOF at the end of the data. With hang set it then blocks until
+// the fetch cancels it.
+type streamPlan struct {
+	bytes int
+	err   error
+	hang  bool
+}
+
+var errUnavailable = status.Error(codes.Unavailable, "connection reset")
+
+// fakeStreams serves data through the streams of plans, one per open, and
+// records the offsets the streams are opened at.
+type fakeStreams struct {
+	data    []byte
+	plans   []streamPlan
+	offsets []int64
+}
+
+func (f *fakeStreams) open(ctx context.Context, offset int64) (chunkStream, error) {
+	plan := f.plans[len(f.offsets)]
+	f.offsets = append(f.offsets, offset)
+	return &fakeStream{ctx: ctx, data: f.data[offset:], plan: plan}, nil
+}
+
+type fakeStream struct {
+	ctx  context.Context
+	data []byte
+	plan streamPlan
+	sent int
+}
+
+func (s *fakeStream) Recv() (*rpc.IMDChunk, error) {
+	if len(s.data) == 0 && s.plan.err == nil && !s.plan.hang {
+		return nil, io.EOF
+	}
+	if len(s.data) > 0 && (s.plan.bytes < 0 || s.sent < s.plan.bytes) {
+		n := 3
+		if s.plan.bytes >= 0 && s.plan.bytes-s.sent < n {
+			n = s.plan.bytes - s.sent
+		}
+		if len(s.data) < n {
+			n = len(s.data)
+		}
+		chunk := s.data[:n]
+		s.data, s.sent = s.data[n:], s.sent+n
+		return &rpc.IMDChunk{Data: chunk}, nil
+	}
+	if s.plan.hang {
+		<-s.ctx.Done()
+		return nil, status.FromContextError(s.ctx.Err()).Err()
+	}
+	return nil, s.plan.err
+}
+
+// failingWriter fails once it holds limit bytes.
+type failingWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.limit {
+		return 0, errDiskFull
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestFetchResuming(t *testing.T) {
+	t.Setenv("MR_FETCH_TIMEOUT_SEC", "1")
+	data := []byte("0123456789abcdef")
+	notFound := status.Error(codes.NotFound, "no such file")
+	tests := []struct {
+		name    string
+		plans   []streamPlan
+		limit   int
+		offsets []int64
+		err     error
+	}{
+		{
+			name:    "whole file",
+			plans:   []streamPlan{{bytes: -1}},
+			offsets: []int64{0},
+		},
+		{
+			name:    "resume after a broken stream",
+			plans:   []streamPlan{{bytes: 5, err: errUnavailable}, {bytes: -1}},
+			offsets: []int64{0, 5},
+		},
+		{
+			name: "progress resets the attempts",
+			plans: []streamPlan{{bytes: 0, err: errUnavailable}, {bytes: 0, err: errUnavailable}, {bytes: 4, err: errUnavailable},
+				{bytes: 0, err: errUnavailable}, {bytes: 0, err: errUnavailable}, {bytes: -1}},
+			offsets: []int64{0, 0, 0, 4, 4, 4},
+		},
+		{
+			name:    "give up after three attempts without progress",
+			plans:   []streamPlan{{bytes: 2, err: errUnavailable}, {err: errUnavailable}, {err: errUnavailable}, {err: errUnavailable}},
+			offsets: []int64{0, 2, 2, 2},
+			err:     errUnavailable,
+		},
+		{
+			name:    "no resume after other errors",
+			plans:   []streamPlan{{bytes: 2, err: notFound}},
+			offsets: []int64{0},
+			err:     notFound,
+		},
+		{
+			name:    "idle stream is canceled and resumed",
+			plans:   []streamPlan{{bytes: 7, hang: true}, {bytes: -1}},
+			offsets: []int64{0, 7},
+		},
+		{
+			name:    "writer error is returned as is",
+			plans:   []streamPlan{{bytes: -1}},
+			limit:   4,
+			offsets: []int64{0},
+			err:     errDiskFull,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streams := &fakeStreams{data: data, plans: tt.plans}
+			w := &failingWriter{limit: len(data)}
+			if tt.limit > 0 {
+				w.limit = tt.limit
+			}
+			err := fetchResuming(streams.open, &countingWriter{w: w}, "test")
+			if fmt.Sprint(streams.offsets) != fmt.Sprint(tt.offsets) {
+				t.Errorf("expect streams opened at %v, got %v", tt.offsets, streams.offsets)
+			}
+			if err != tt.err {
+				t.Fatalf("expect error %v, got %v", tt.err, err)
+			}
+			if err == nil && w.String() != string(data) {
+				t.Errorf("expect %q, got %q", data, w.String())
+			}
+		})
+	}
+}
+
+// chunkRecorder is the server side of a FetchIMD stream.
+type chunkRecorder struct {
+	grpc.ServerStream
+	data []byte
+}
+
+func (r *chunkRecorder) Send(chunk *rpc.IMDChunk) error {
+	r.data = append(r.data, chunk.Data...)
+	return nil
+}
+
+func TestFetchIMDServerOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "imd")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wr := &Worker{}
+	for offset, want := range map[int64]string{0: "0123456789", 4: "456789", 10: ""} {
+		rec := &chunkRecorder{}
+		if err := wr.FetchIMD(&rpc.IMDLoc{Filename: path, Offset: offset}, rec); err != nil || string(rec.data) != want {
+			t.Errorf("offset %v: expect %q, got %q, %v", offset, want, rec.data, err)
+		}
+	}
+	if err := wr.FetchIMD(&rpc.IMDLoc{Filename: path + "-lost"}, &chunkRecorder{}); status.Code(err) != codes.NotFound {
+		t.Errorf("expect NotFound for a missing file, got %v", err)
+	}
+}
+
+func TestGetIMDDataServesLegacyFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "imd")
+	raw := encodeIMD(t, []KV{newKV("a", "1"), newKV("b", "2")}, imdCodecs[0])
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wr := &Worker{}
+	got, err := wr.GetIMDData(context.Background(), &rpc.IMDLoc{Filename: path})
+	if err != nil || got.Kvs != "a\t1\nb\t2\n" {
+		t.Errorf("expect the legacy lines, got %q, %v", got.GetKvs(), err)
+	}
+	if _, err := wr.GetIMDData(context.Background(), &rpc.IMDLoc{Filename: path + "-lost"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expect NotFound for a missing file, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
+
+// sampleWindows is the number of places a split is sampled at.
+const sampleWindows = 8
+
+// runSample runs the map function of the plugin over about in.SampleBytes of
+// the input of a map task and returns up to in.SampleKeys of the keys it
+// emits, picked uniformly. The master derives the split points of a range
+// partitioned job from them. Setup and Cleanup run around it as for map
+// tasks.
+func runSample(in *rpc.MapInfo, funcs pluginFuncs, task *TaskInfo) ([]string, error) {
+	var keys []string
+	err := withHooks(funcs, task, func() (err error) {
+		keys, err = sampleTask(in, funcs, task)
+		return err
+	})
+	return keys, err
+}
+
+func sampleTask(in *rpc.MapInfo, funcs pluginFuncs, task *TaskInfo) ([]string, error) {
+	windows := make([][]sampleWindow, len(in.Files))
+	for i, fInfo := range in.Files {
+		w, err := sampleContent(in.InputFormat, fInfo, in.SampleBytes/int64(len(in.Files)))
+		if err != nil {
+			return nil, err
+		}
+		windows[i] = w
+	}
+
+	ctx := newMrContext()
+	ctx.task = task
+	var mapErr error
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				mapErr = fmt.Errorf("map panic while sampling: %v", r)
+			}
+			close(ctx.Chan)
+		}()
+		for i, fInfo := range in.Files {
+			if funcs.maprecordf == nil {
+				var b strings.Builder
+				for _, w := range windows[i] {
+					b.Write(w.data)
+				}
+				funcs.mapf(fInfo.FileName, b.String(), ctx)
+				continue
+			}
+			for _, w := range windows[i] {
+				records := newRecordReader(in.InputFormat, fInfo.FileName, bytes.NewReader(w.data), w.base)
+				if mapErr = mapRecords(records, funcs.maprecordf, ctx); mapErr != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	max := int(in.SampleKeys)
+	keys := make([]string, 0, max)
+	rng := rand.New(rand.NewSource(in.Id))
+	seen := 0
+	for kv := range ctx.Chan {
+		if kv.output != "" {
+			continue
+		}
+		seen++
+		if len(keys) < max {
+			keys = append(keys, kv.Key)
+		} else if i := rng.Intn(seen); i < max {
+			keys[i] = kv.Key
+		}
+	}
+	if mapErr != nil {
+		return nil, mapErr
+	}
+	return keys, nil
+}
+
+// sampleWindow is a run of whole records starting at byte base of the input.
+type sampleWindow struct {
+	base int64
+	data []byte
+}
+
+// sampleContent returns about budget bytes of whole records of a split, or
+// the whole split if it is smaller. Line formats are read from sampleWindows
+// evenly spaced places. CSV and gzip files are read from the start, since a
+// record boundary cannot be found in the middle of them, and whole files are
+// read entirely.
+func sampleContent(format string, fInfo *rpc.MapFileInfo, budget int64) ([]sampleWindow, error) {
+	switch {
+	case format == "gzip":
+		return sampleGzip(fInfo, budget)
+	case format == "whole" || fInfo.To-fInfo.From <= budget:
+		content, err := partialContent(fInfo)
+		return []sampleWindow{{base: fInfo.From, data: []byte(content)}}, err
+	}
+	f, err := os.Open(fInfo.FileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == "csv" {
+		buf := make([]byte, budget)
+		n, err := f.ReadAt(buf, fInfo.From)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return []sampleWindow{{base: fInfo.From, data: buf[:csvRecordsEnd(buf[:n])]}}, nil
+	}
+
+	size := fInfo.To - fInfo.From
+	var windows []sampleWindow
+	buf := make([]byte, budget/sampleWindows)
+	for i := int64(0); i < sampleWindows; i++ {
+		at := fInfo.From + i*size/sampleWindows
+		n, err := f.ReadAt(buf, at)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		window := buf[:n]
+		// Splits start on a line, other windows drop their cut first line.
+		if i > 0 {
+			start := bytes.IndexByte(window, '\n')
+			if start < 0 {
+				continue
+			}
+			window, at = window[start+1:], at+int64(start+1)
+		}
+		end := bytes.LastIndexByte(window, '\n')
+		if end < 0 {
+			continue
+		}
+		windows = append(windows, sampleWindow{base: at, data: append([]byte(nil), window[:end+1]...)})
+	}
+	return windows, nil
+}
+
+// sampleGzip returns the whole lines of about the first budget bytes of a
+// gzip file once decompressed.
+func sampleGzip(fInfo *rpc.MapFileInfo, budget int64) ([]sampleWindow, error) {
+	f, err := os.Open(fInfo.FileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", fInfo.FileName, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(gz, budget+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > budget {
+		data = data[:bytes.LastIndexByte(data[:budget], '\n')+1]
+	}
+	return []sampleWindow{{data: data}}, nil
+}
+
+// csvRecordsEnd returns the length of the whole CSV records at the start of
+// b: the end of its last line that closes every quote.
+func csvRecordsEnd(b []byte) int {
+	end, quotes := 0, 0
+	for i, c := range b {
+		switch c {
+		case '"':
+			quotes++
+		case '\n':
+			if quotes%2 == 0 {
+				end = i + 1
+			}
+		}
+	}
+	return end
+}
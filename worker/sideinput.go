@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+	log "github.com/sirupsen/logrus"
+)
+
+// sideInputs are the params and cache files of a job on this worker.
+type sideInputs struct {
+	params map[string]string
+	// files maps the names of the cache files to their local copies.
+	files map[string]string
+}
+
+// Param returns the job parameter key, or "" if the job has none.
+func (mc *MrContext) Param(key string) string {
+	return mc.task.Param(key)
+}
+
+// CacheFile returns the path of the local copy of a job cache file, see
+// TaskInfo.CacheFile.
+func (mc *MrContext) CacheFile(name string) (string, error) {
+	return mc.task.CacheFile(name)
+}
+
+// cacheDir holds the cache files of the jobs this worker ran tasks of.
+func (wr *Worker) cacheDir() string {
+	return filepath.Join(os.TempDir(), "mr-cache-"+wr.UUID)
+}
+
+// taskSideInputs returns the side inputs of the job of a task. The cache files
+// are downloaded from the master on the first task of the job and kept until
+// the master reports the job as ended.
+func (wr *Worker) taskSideInputs(task *rpc.Task) (*sideInputs, error) {
+	jobID := taskJobID(task)
+	wr.mux.Lock()
+	side, ok := wr.side[jobID]
+	wr.mux.Unlock()
+	if ok {
+		return side, nil
+	}
+
+	side = &sideInputs{params: task.Params, files: make(map[string]string)}
+	if len(task.CacheFiles) > 0 {
+		dir := filepath.Join(wr.cacheDir(), jobID)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		var bytes int64
+		for _, cf := range task.CacheFiles {
+			path, err := wr.fetchCacheFile(jobID, cf, dir)
+			if err != nil {
+				return nil, err
+			}
+			side.files[cf.Name] = path
+			bytes += cf.Size
+		}
+		log.Info(fmt.Sprintf("[Worker] Downloaded %v cache files of job %v, %v bytes", len(task.CacheFiles), jobID, bytes))
+	}
+	wr.mux.Lock()
+	wr.side[jobID] = side
+	wr.mux.Unlock()
+	return side, nil
+}
+
+// fetchCacheFile downloads a cache file into dir and checks its size.
+func (wr *Worker) fetchCacheFile(jobID string, cf *rpc.CacheFile, dir string) (string, error) {
+	if cf.Name == "" || cf.Name == "." || cf.Name == ".." || strings.ContainsAny(cf.Name, `/\`) {
+		return "", fmt.Errorf("invalid cache file name %q", cf.Name)
+	}
+	f, err := os.CreateTemp(dir, "."+cf.Name+"-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	err = wr.Client.FetchCacheFile(jobID, cf.Name, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("fetch cache file %v: %v", cf.Name, err)
+	}
+	fi, err := os.Stat(f.Name())
+	if err != nil {
+		return "", err
+	}
+	if fi.Size() != cf.Size {
+		return "", fmt.Errorf("fetch cache file %v: got %v of %v bytes", cf.Name, fi.Size(), cf.Size)
+	}
+	path := filepath.Join(dir, cf.Name)
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// dropSideInputs deletes the side inputs of jobs that have ended.
+func (wr *Worker) dropSideInputs(jobIDs []string) {
+	wr.mux.Lock()
+	defer wr.mux.Unlock()
+	for _, id := range jobIDs {
+		if _, ok := wr.side[id]; !ok {
+			continue
+		}
+		delete(wr.side, id)
+		os.RemoveAll(filepath.Join(wr.cacheDir(), id))
+	}
+}
+
+func taskJobID(task *rpc.Task) string {
+	if task.Type == rpc.Task_REDUCE {
+		return task.Reduce.JobId
+	}
+	return task.Map.JobId
+}
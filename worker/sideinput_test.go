@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"os"
+	"testing"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+	"github.com/emptyOVO/mrkit-go/worker/mocks"
+)
+
+func TestSideInputs(t *testing.T) {
+	wr := &Worker{UUID: "side-test", Client: &mocks.MasterClient{}, side: make(map[string]*sideInputs)}
+	defer os.RemoveAll(wr.cacheDir())
+	task := &rpc.Task{
+		Type:       rpc.Task_MAP,
+		Map:        &rpc.MapInfo{JobId: "job"},
+		Params:     map[string]string{"n": "3"},
+		CacheFiles: []*rpc.CacheFile{{Name: "dim.tsv", Size: 4}},
+	}
+	mocks.Result = "a\t1\n"
+	side, err := wr.taskSideInputs(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := MrContext{task: &TaskInfo{side: side}}
+	if ctx.Param("n") != "3" || ctx.Param("missing") != "" {
+		t.Error("tasks should see the params of their job")
+	}
+	path, err := ctx.CacheFile("dim.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "a\t1\n" {
+		t.Errorf("expected the downloaded cache file, got %q, %v", b, err)
+	}
+	if _, err := ctx.CacheFile("other.tsv"); err == nil {
+		t.Error("expected an error for a file the job does not have")
+	}
+
+	// Files are downloaded once per job and checked against their size.
+	mocks.Result = "truncated"
+	if again, err := wr.taskSideInputs(task); err != nil || again != side {
+		t.Error("the side inputs of a job should be reused")
+	}
+	task.Map.JobId = "other"
+	if _, err := wr.taskSideInputs(task); err == nil {
+		t.Error("expected an error for a cache file of the wrong size")
+	}
+	task.Map.JobId = "job"
+	if again, err := wr.taskSideInputs(task); err != nil || again != side {
+		t.Error("the side inputs of a job should outlive tasks of other jobs")
+	}
+
+	// Side inputs are dropped once the master reports their job as ended.
+	if jobs := wr.heldJobs(); len(jobs) != 1 || jobs[0] != "job" {
+		t.Errorf("expect to hold side inputs of the job, got %v", jobs)
+	}
+	wr.dropSideInputs([]string{"job"})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the cache files of an ended job should be deleted")
+	}
+	if jobs := wr.heldJobs(); len(jobs) != 0 {
+		t.Errorf("expect no held jobs, got %v", jobs)
+	}
+}
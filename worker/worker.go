@@ -1,19 +1,23 @@
 package worker
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"io"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/emptyOVO/mrkit-go/rpc"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type MapFormat (func(string, string, MrContext))
@@ -24,7 +28,23 @@ type Worker struct {
 	ID         int
 	nReduce    int
 	Mapf       MapFormat
+	MapRecordf MapRecordFormat
 	Reducef    ReduceFormat
+	Combinef   ReduceFormat
+	Partitionf PartitionFormat
+	// Comparef and GroupComparef are the optional Compare and GroupCompare
+	// functions of the plugin.
+	Comparef      CompareFormat
+	GroupComparef CompareFormat
+	// Setupf and Cleanupf are the optional Setup and Cleanup functions.
+	Setupf   HookFormat
+	Cleanupf HookFormat
+	plugins  map[string]pluginFuncs
+	// side holds the side inputs of each job by ID.
+	side map[string]*sideInputs
+	// imdFiles holds the intermediate files of each job by ID, until the
+	// master says the job has ended.
+	imdFiles   map[string][]string
 	Chan       MrContext
 	EndChan    chan bool
 	storeInRAM bool
@@ -41,6 +61,9 @@ func newWorker(nReduce int, inRAM bool) rpc.WorkerServer {
 		nReduce:    nReduce,
 		Chan:       newMrContext(),
 		EndChan:    make(chan bool),
+		plugins:    make(map[string]pluginFuncs),
+		side:       make(map[string]*sideInputs),
+		imdFiles:   make(map[string][]string),
 		Client:     &masterClient{master: master, conn: conn},
 		storeInRAM: inRAM,
 		State:      rpc.WorkerState_IDLE,
@@ -54,32 +77,111 @@ func (wr *Worker) Map(ctx context.Context, in *rpc.MapInfo) (*rpc.Result, error)
 
 	wr.setWorkerState(rpc.WorkerState_BUSY)
 
+	filenames, _, err := wr.runMap(in, wr.defaultFuncs(), nil, mapTaskInfo(in, 0, nil))
+	if err != nil {
+		wr.setWorkerState(rpc.WorkerState_IDLE)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.Trace("[Worker] Tell Master the intermediate info")
+	// Return to the Master
+	wr.Client.UpdateIMDInfo(&rpc.IMDInfo{
+		Uuid:      wr.UUID,
+		Filenames: filenames,
+	})
+	log.Trace("[Worker] Finish Tell Master the intermediate info")
+	log.Info("[Worker] Finish Map Task")
+	wr.setWorkerState(rpc.WorkerState_IDLE)
+
+	return &rpc.Result{Result: true}, nil
+}
+
+// runMap executes a map task and returns one intermediate file per reducer
+// along with the number of records written to them. Each split goes to
+// MapRecord record by record in the input format of the job, or to Map as one
+// string if the plugin has no MapRecord.
+// A panic of a plugin function fails the task instead of the worker. The
+// optional Combine function pre-aggregates each partition before it is
+// written, Partition places the keys unless the job is range partitioned, and
+// Compare sorts them. The optional Setup and Cleanup functions run around the
+// attempt, and a Cleanup error fails it.
+func (wr *Worker) runMap(in *rpc.MapInfo, funcs pluginFuncs, counters *taskCounters, task *TaskInfo) ([]string, int64, error) {
+	var filenames []string
+	var records int64
+	err := withHooks(funcs, task, func() (err error) {
+		filenames, records, err = wr.mapTask(in, funcs, counters, task)
+		return err
+	})
+	if err == nil {
+		err = task.outputs.close()
+	}
+	if err != nil {
+		discardFiles(filenames...)
+		task.outputs.discard()
+		return nil, 0, err
+	}
+	return filenames, records, nil
+}
+
+func (wr *Worker) mapTask(in *rpc.MapInfo, funcs pluginFuncs, counters *taskCounters, task *TaskInfo) ([]string, int64, error) {
+	codec, err := codecByName(in.Compression)
+	if err != nil {
+		return nil, 0, err
+	}
 	log.Trace("[Worker] Start Mapping")
 	done := make(chan int, 100)
+	errs := make(chan error, len(in.Files))
 	mapChan := newMrContext()
+	mapChan.counters = counters
+	mapChan.task = task
 	for _, fInfo := range in.Files {
-		content := partialContent(fInfo)
-		go func(f0 *rpc.MapFileInfo, c0 string) {
-			wr.Mapf(f0.FileName, c0, mapChan)
-			done <- 1
-		}(fInfo, content)
+		go func(f0 *rpc.MapFileInfo) {
+			defer func() {
+				if r := recover(); r != nil {
+					errs <- fmt.Errorf("map %v panic: %v", f0.FileName, r)
+				}
+				done <- 1
+			}()
+			if err := mapSplit(in.InputFormat, f0, funcs, mapChan); err != nil {
+				errs <- err
+			}
+		}(fInfo)
 	}
 	log.Trace("[Worker] Finish Mapping")
 
-	imdKV := make([][]KV, wr.nReduce)
+	nReduce := int(in.NReduce)
+	if nReduce <= 0 {
+		nReduce = wr.nReduce
+	}
+	order := newKeyOrder(funcs.comparef, funcs.groupf)
+	buffer := newMapOutputBuffer(nReduce, mapBufferBudget(), withTask(funcs.combinef, task), codec, order)
+	defer buffer.close()
+	partition := partitioner(in, funcs.partitionf, nReduce)
 
 	// Get intermediate KV
 	// Partition result into R piece
 	log.Trace("[Worker] Start partition intermediate kv")
 	count := 0
+	var addErr error
+	if len(in.Files) == 0 {
+		close(mapChan.Chan)
+	}
 
 LOOP:
 	for {
 		select {
 		case mapKV, haveKV := <-mapChan.Chan:
 			if haveKV {
-				reducerID := reducerForKey(mapKV.Key, wr.nReduce)
-				imdKV[reducerID] = append(imdKV[reducerID], mapKV)
+				// Keep draining after a failed partition or spill so
+				// the map goroutines can finish.
+				if addErr == nil && mapKV.output != "" {
+					addErr = task.outputs.write(mapKV)
+				} else if addErr == nil {
+					var part int
+					if part, addErr = partition(mapKV.Key); addErr == nil {
+						addErr = buffer.add(part, mapKV)
+					}
+				}
 			} else {
 				break LOOP
 			}
@@ -93,28 +195,68 @@ LOOP:
 
 	}
 	log.Trace("[Worker] End partition intermediate kv")
+	select {
+	case err := <-errs:
+		return nil, 0, err
+	default:
+	}
+	if addErr != nil {
+		return nil, 0, addErr
+	}
+	if n := buffer.spills(); n > 0 {
+		log.Info(fmt.Sprintf("[Worker] Map task %v spilled its output %v times", in.Id, n))
+	}
 
 	log.Trace("[Worker] Write intermediate kv to file")
-	filenames := writeIMDToLocalFile(imdKV, wr.UUID, wr.storeInRAM)
+	its, err := buffer.iterators()
+	if err != nil {
+		return nil, 0, err
+	}
+	filenames, records, err := writeIMDToLocalFile(its, in.JobId, wr.UUID, in.Id, wr.storeInRAM, codec)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := order.err(); err != nil {
+		discardFiles(filenames...)
+		return nil, 0, err
+	}
 	log.Trace("[Worker] End Write intermediate kv to file")
+	return filenames, records, nil
+}
 
-	log.Trace("[Worker] Tell Master the intermediate info")
-	// Return to the Master
-	wr.Client.UpdateIMDInfo(&rpc.IMDInfo{
-		Uuid:      wr.UUID,
-		Filenames: filenames,
-	})
-	log.Trace("[Worker] Finish Tell Master the intermediate info")
-	log.Info("[Worker] Finish Map Task")
-	wr.setWorkerState(rpc.WorkerState_IDLE)
+// mapSplit feeds a split to MapRecord one record at a time, or to Map as one
+// string if the plugin has no MapRecord.
+func mapSplit(format string, fInfo *rpc.MapFileInfo, funcs pluginFuncs, ctx MrContext) error {
+	if funcs.maprecordf == nil {
+		content, err := splitContent(format, fInfo)
+		if err != nil {
+			return err
+		}
+		funcs.mapf(fInfo.FileName, content, ctx)
+		return nil
+	}
+	records, f, err := openRecords(format, fInfo)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return mapRecords(records, funcs.maprecordf, ctx)
+}
 
-	return &rpc.Result{Result: true}, nil
+func mapRecords(records recordReader, maprecordf MapRecordFormat, ctx MrContext) error {
+	for {
+		key, value, ok, err := records.next()
+		if err != nil || !ok {
+			return err
+		}
+		maprecordf(key, value, ctx)
+	}
 }
 
-func partialContent(fInfo *rpc.MapFileInfo) string {
+func partialContent(fInfo *rpc.MapFileInfo) (string, error) {
 	f, err := os.Open(fInfo.FileName)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 
 	defer f.Close()
@@ -122,37 +264,49 @@ func partialContent(fInfo *rpc.MapFileInfo) string {
 	start := fInfo.From
 	end := fInfo.To
 	if end < start {
-		return ""
+		return "", nil
 	}
 	if _, err := f.Seek(start, io.SeekStart); err != nil {
-		panic(err)
+		return "", err
 	}
 	size := end - start
 	if size <= 0 {
-		return ""
+		return "", nil
 	}
 	buf := make([]byte, size)
 	_, err = io.ReadFull(f, buf)
 	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
-		panic(err)
+		return "", err
 	}
-	return string(buf)
+	return string(buf), nil
 }
 
-func writeIMDToLocalFile(imdKV [][]KV, uuid string, inRAM bool) []string {
+// writeIMDToLocalFile writes each partition, in key order, to its own
+// intermediate file and returns the number of records written.
+func writeIMDToLocalFile(its []kvIterator, jobID string, uuid string, mapID int64, inRAM bool, codec imdCodec) ([]string, int64, error) {
 	// Filenames must stay aligned with reducer index, otherwise master will
 	// dispatch wrong partitions to reducers and produce duplicate outputs.
-	filenames := make([]string, len(imdKV))
+	filenames := make([]string, len(its))
+	records := make([]int64, len(its))
+	errs := make([]error, len(its))
 	var wg sync.WaitGroup
-	for taskID, kvs := range imdKV {
+	for taskID, it := range its {
 		wg.Add(1)
-		go func(t int, s []KV) {
+		go func(t int, it kvIterator) {
 			defer wg.Done()
-			filenames[t] = writeIMDToLocalFileParallel(t, s, uuid, inRAM)
-		}(taskID, kvs)
+			filenames[t], records[t], errs[t] = writeIMDToLocalFileParallel(t, it, jobID, uuid, mapID, inRAM, codec)
+		}(taskID, it)
 	}
 	wg.Wait()
-	return filenames
+	var total int64
+	for i, err := range errs {
+		if err != nil {
+			discardFiles(filenames...)
+			return nil, 0, err
+		}
+		total += records[i]
+	}
+	return filenames, total, nil
 }
 
 func reducerForKey(key string, nReduce int) int {
@@ -164,92 +318,459 @@ func reducerForKey(key string, nReduce int) int {
 	return int(h.Sum32()&0x7fffffff) % nReduce
 }
 
-func writeIMDToLocalFileParallel(taskId int, kvs []KV, uuid string, inRAM bool) string {
-	content := encodeIMDKVs(kvs)
+// fileSizes returns the size of each file, 0 for files it cannot stat.
+func fileSizes(filenames []string) []int64 {
+	sizes := make([]int64, len(filenames))
+	for i, f := range filenames {
+		if info, err := os.Stat(f); err == nil {
+			sizes[i] = info.Size()
+		}
+	}
+	return sizes
+}
+
+// imdFileName names the intermediate file of one partition. Jobs sharing a
+// worker never collide since the job ID is part of the name.
+func imdFileName(jobID string, uuid string, mapID int64, taskId int) string {
+	if jobID == "" {
+		return fmt.Sprintf("imd-%v-%v-%v.txt", uuid, mapID, taskId)
+	}
+	return fmt.Sprintf("imd-%v-%v-%v-%v.txt", jobID, uuid, mapID, taskId)
+}
 
+func writeIMDToLocalFileParallel(taskId int, it kvIterator, jobID string, uuid string, mapID int64, inRAM bool, codec imdCodec) (string, int64, error) {
 	var fname string
 	if inRAM {
 		baseDir := "/dev/shm"
 		if info, err := os.Stat(baseDir); err != nil || !info.IsDir() {
 			baseDir = os.TempDir()
 		}
-		fname = filepath.Join(baseDir, fmt.Sprintf("imd-%v-%v.txt", uuid, taskId))
+		fname = filepath.Join(baseDir, imdFileName(jobID, uuid, mapID, taskId))
 	} else {
-		fname = fmt.Sprintf("output/imd-%v-%v.txt", uuid, taskId)
+		fname = filepath.Join("output", imdFileName(jobID, uuid, mapID, taskId))
 	}
 	if err := os.MkdirAll(filepath.Dir(fname), 0o755); err != nil {
-		panic(err)
-	}
-	file, err := os.Create(fname)
-	if err != nil {
-		panic(err)
+		return "", 0, err
 	}
-	defer file.Close()
-
-	file.WriteString(content)
-	return fname
+	n, err := writeRun(fname, it, codec)
+	return fname, n, err
 }
 
 func (wr *Worker) Reduce(ctx context.Context, in *rpc.ReduceInfo) (*rpc.Result, error) {
 	log.Info("[Worker] Start Reduce")
 
 	wr.setWorkerState(rpc.WorkerState_BUSY)
+	output, _, err := wr.runReduce(in, wr.defaultFuncs(), nil, reduceTaskInfo(in, 0, nil))
+	wr.setWorkerState(rpc.WorkerState_IDLE)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err := commitOutput(output, in.OutputDir, in.Id); err != nil {
+		discardFiles(output)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	log.Info("[Worker] End Reduce")
 
+	return &rpc.Result{Result: true}, nil
+}
+
+// runReduce executes a reduce task into a temporary file and returns its name
+// and the number of records written.
+// The file only becomes mr-out-<id>.txt through commitOutput, so a failed or
+// duplicate attempt never leaves a half-written result behind. It fails with a
+// *fetchError if an intermediate file cannot be read.
+//
+// Map outputs are streamed in chunks, arrive sorted and are merged rather than
+// sorted again. They are held within MR_REDUCE_MEMORY_MB; a larger partition
+// is spilled to local disk in sorted runs that are merged back while reducing,
+// so only the values of one key need to fit in memory.
+//
+// Keys are sorted by the plugin Compare function and each group of keys equal
+// under GroupCompare makes one Reduce call with the first key of the group.
+// Setup and Cleanup run around the attempt as for map tasks.
+func (wr *Worker) runReduce(in *rpc.ReduceInfo, funcs pluginFuncs, counters *taskCounters, task *TaskInfo) (string, int64, error) {
+	var output string
+	var records int64
+	err := withHooks(funcs, task, func() (err error) {
+		output, records, err = wr.reduceTask(in, funcs, counters, task)
+		return err
+	})
+	if err == nil {
+		err = task.outputs.close()
+	}
+	if err != nil {
+		if output != "" {
+			discardFiles(output)
+		}
+		task.outputs.discard()
+		return "", 0, err
+	}
+	return output, records, nil
+}
+
+func (wr *Worker) reduceTask(in *rpc.ReduceInfo, funcs pluginFuncs, counters *taskCounters, task *TaskInfo) (string, int64, error) {
 	log.Trace("[Worker] Get intermediate file")
-	var imdKVs []KV
+	codec, err := codecByName(in.Compression)
+	if err != nil {
+		return "", 0, err
+	}
+	order := newKeyOrder(funcs.comparef, funcs.groupf)
+	sorter := newExternalSorter(reduceMemoryBudget(), codec, order)
+	defer sorter.close()
 	for _, fInfo := range in.Files {
-		imdKVs = append(imdKVs, wr.Client.GetIMDData(fInfo.Ip, fInfo.Filename)...)
+		rw := sorter.newRunWriter()
+		if err := wr.Client.FetchIMD(fInfo.Ip, fInfo.Filename, rw); err != nil {
+			return "", 0, err
+		}
+		if err := rw.close(); err != nil {
+			if errors.Is(err, errCorruptIMD) {
+				// Have the master regenerate the output.
+				return "", 0, &fetchError{IP: fInfo.Ip, Filename: fInfo.Filename, err: err}
+			}
+			return "", 0, err
+		}
 	}
 
-	log.Trace("[Worker] Sort intermediate KV")
-	// Sort
-	sort.Sort(byKey(imdKVs))
+	log.Trace("[Worker] Merge intermediate KV")
+	if sorter.spilled() {
+		log.Info(fmt.Sprintf("[Worker] Reduce task %v spilled %v sorted runs", in.Id, len(sorter.runs)))
+	}
+	it, err := sorter.iterator()
+	if err != nil {
+		return "", 0, err
+	}
 
-	outputFile := fmt.Sprintf("mr-out-%v.txt", wr.ID)
-	ofile, _ := os.Create(outputFile)
+	dir := in.OutputDir
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", 0, err
+	}
+	ofile, err := os.CreateTemp(dir, fmt.Sprintf(".mr-out-%v-*", in.Id))
+	if err != nil {
+		return "", 0, err
+	}
+	abort := func(err error) (string, int64, error) {
+		ofile.Close()
+		os.Remove(ofile.Name())
+		return "", 0, err
+	}
 
 	log.Trace("[Worker] Start Reducing")
-	// Reduce all the intermediate KV
-	reduceChan := newMrContext()
-	i := 0
-	for i < len(imdKVs) {
-		j := i + 1
-		for j < len(imdKVs) && imdKVs[j].Key == imdKVs[i].Key {
-			j++
-		}
+	// Reduce all the intermediate KV, one group of keys at a time
+	w := bufio.NewWriter(ofile)
+	var records int64
+	kv, ok, err := it.next()
+	for ok && err == nil {
+		key := kv.Key
 		values := []string{}
-		for k := i; k < j; k++ {
-			values = append(values, imdKVs[k].Value)
+		for ok && err == nil && order.sameGroup(key, kv.Key) {
+			values = append(values, kv.Value)
+			kv, ok, err = it.next()
 		}
-		wr.Reducef(imdKVs[i].Key, values, reduceChan)
+		if err != nil {
+			break
+		}
+		n, err := callReduce(funcs.reducef, key, values, w, counters, task)
+		if err != nil {
+			return abort(err)
+		}
+		records += n
+	}
+	if err == nil {
+		err = order.err()
+	}
+	if err != nil {
+		return abort(err)
+	}
+	if err := w.Flush(); err != nil {
+		return abort(err)
+	}
+	ofile.Close()
+	log.Trace("[Worker] End Reducing")
+	return ofile.Name(), records, nil
+}
 
-		output := <-reduceChan.Chan
+// callReduce runs reducef on one key and writes every pair it emits, in
+// order, returning how many. A reducer may emit any number of pairs, none
+// included. A panic is turned into an error.
+func callReduce(reducef ReduceFormat, key string, values []string, w io.Writer, counters *taskCounters, task *TaskInfo) (int64, error) {
+	ctx := newMrContext()
+	ctx.counters = counters
+	ctx.task = task
+	var panicErr error
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicErr = fmt.Errorf("reduce %v panic: %v", key, r)
+			}
+			close(ctx.Chan)
+		}()
+		reducef(key, values, ctx)
+	}()
+	var n int64
+	var err error
+	for kv := range ctx.Chan {
+		if kv.output == "" {
+			fmt.Fprintf(w, "%v %v\n", kv.Key, kv.Value)
+			n++
+		} else if err == nil {
+			err = task.outputs.write(kv)
+		}
+	}
+	if panicErr != nil {
+		return 0, panicErr
+	}
+	return n, err
+}
 
-		fmt.Fprintf(ofile, "%v %v\n", output.Key, output.Value)
+func commitOutput(tmpFile string, dir string, id int64) error {
+	if err := os.Rename(tmpFile, filepath.Join(dir, fmt.Sprintf("mr-out-%v.txt", id))); err != nil {
+		return fmt.Errorf("commit output: %v", err)
+	}
+	return nil
+}
 
-		i = j
+func discardFiles(files ...string) {
+	for _, f := range files {
+		_ = os.Remove(f)
 	}
-	log.Trace("[Worker] End Reducing")
-	log.Info("[Worker] End Reduce")
-	wr.setWorkerState(rpc.WorkerState_IDLE)
+}
 
-	return &rpc.Result{Result: true}, nil
+// keepIMDFiles remembers the intermediate files of a map task, to delete them
+// once the job has ended.
+func (wr *Worker) keepIMDFiles(jobID string, filenames []string) {
+	wr.mux.Lock()
+	defer wr.mux.Unlock()
+	wr.imdFiles[jobID] = append(wr.imdFiles[jobID], filenames...)
 }
 
-func (wr *Worker) GetIMDData(ctx context.Context, in *rpc.IMDLoc) (*rpc.JSONKVs, error) {
-	log.Info("[Worker] RPC Get intermediate file")
-	return &rpc.JSONKVs{
-		Kvs: generateIMDKV(in.Filename),
-	}, nil
+// heldJobs returns the jobs this worker holds intermediate files or side
+// inputs of.
+func (wr *Worker) heldJobs() []string {
+	wr.mux.Lock()
+	defer wr.mux.Unlock()
+	jobs := make([]string, 0, len(wr.imdFiles)+len(wr.side))
+	for id := range wr.imdFiles {
+		jobs = append(jobs, id)
+	}
+	for id := range wr.side {
+		if _, ok := wr.imdFiles[id]; !ok {
+			jobs = append(jobs, id)
+		}
+	}
+	return jobs
+}
+
+// dropIMDFiles deletes the intermediate files of jobs that have ended. No
+// reducer fetches them any more, and a long-lived worker would otherwise fill
+// its disk, or /dev/shm with -m=true, with them.
+func (wr *Worker) dropIMDFiles(jobIDs []string) {
+	wr.mux.Lock()
+	defer wr.mux.Unlock()
+	for _, id := range jobIDs {
+		if _, ok := wr.imdFiles[id]; !ok {
+			continue
+		}
+		discardFiles(wr.imdFiles[id]...)
+		delete(wr.imdFiles, id)
+		log.Info("[Worker] Deleted the intermediate files of job ", id)
+	}
+}
+
+// pullTasks asks the master for work until it is told that the job is over.
+// It returns the error of a task request that failed for good, after the
+// retries of callMaster.
+func (wr *Worker) pullTasks() error {
+	const pollInterval = 200 * time.Millisecond
+	for {
+		task, err := wr.Client.RequestTask(&rpc.WorkerInfo{Uuid: wr.UUID, Jobs: wr.heldJobs()})
+		if err != nil {
+			return err
+		}
+		wr.dropIMDFiles(task.EndedJobs)
+		wr.dropSideInputs(task.EndedJobs)
+
+		var funcs pluginFuncs
+		var side *sideInputs
+		if task.Type == rpc.Task_MAP || task.Type == rpc.Task_REDUCE {
+			if funcs, err = wr.taskFuncs(task); err != nil {
+				log.Warn("[Worker] Load job functions failed: ", err)
+				wr.Client.ReportTask(&rpc.TaskResult{Uuid: wr.UUID, TaskUuid: task.Uuid, Result: false, Error: err.Error()})
+				continue
+			}
+			if side, err = wr.taskSideInputs(task); err != nil {
+				log.Warn("[Worker] Load side inputs failed: ", err)
+				wr.Client.ReportTask(&rpc.TaskResult{Uuid: wr.UUID, TaskUuid: task.Uuid, Result: false, Error: err.Error()})
+				continue
+			}
+		}
+
+		switch task.Type {
+		case rpc.Task_MAP:
+			if task.Map.SampleKeys > 0 {
+				log.Info("[Worker] Sample Map task ", task.Map.Id, " of job ", task.Map.JobId)
+				wr.setWorkerState(rpc.WorkerState_BUSY)
+				keys, err := runSample(task.Map, funcs, mapTaskInfo(task.Map, task.Attempt, side))
+				wr.setWorkerState(rpc.WorkerState_IDLE)
+				result := &rpc.TaskResult{Uuid: wr.UUID, TaskUuid: task.Uuid, Result: err == nil, SampleKeys: keys,
+					CustomCompare: funcs.comparef != nil}
+				if err != nil {
+					log.Warn("[Worker] Sampling Map task ", task.Map.Id, " failed: ", err)
+					result.Error = err.Error()
+				}
+				wr.Client.ReportTask(result)
+				continue
+			}
+			log.Info("[Worker] Start Map task ", task.Map.Id, " of job ", task.Map.JobId)
+			wr.setWorkerState(rpc.WorkerState_BUSY)
+			counters := newTaskCounters()
+			info := mapTaskInfo(task.Map, task.Attempt, side)
+			info.outputs = newNamedOutputs(task.Map.OutputDir)
+			filenames, records, err := wr.runMap(task.Map, funcs, counters, info)
+			wr.setWorkerState(rpc.WorkerState_IDLE)
+			if err != nil {
+				log.Warn("[Worker] Map task ", task.Map.Id, " failed: ", err)
+				wr.Client.ReportTask(&rpc.TaskResult{Uuid: wr.UUID, TaskUuid: task.Uuid, Result: false, Error: err.Error()})
+				continue
+			}
+			wr.keepIMDFiles(task.Map.JobId, filenames)
+			if !wr.Client.ReportTask(&rpc.TaskResult{
+				Uuid:      wr.UUID,
+				TaskUuid:  task.Uuid,
+				Result:    true,
+				Filenames: filenames,
+				Sizes:     fileSizes(filenames),
+				Records:   records,
+				Counters:  counters.toRPC(),
+			}) {
+				// Another copy of the task won, drop ours.
+				discardFiles(filenames...)
+				info.outputs.discard()
+			} else if err := info.outputs.commit(fmt.Sprintf("m-%v", task.Map.Id)); err != nil {
+				// The master runs the task again.
+				log.Warn("[Worker] Commit named outputs of Map task ", task.Map.Id, " failed: ", err)
+				info.outputs.discard()
+				wr.Client.ReportTask(&rpc.TaskResult{Uuid: wr.UUID, TaskUuid: task.Uuid, Result: false, Error: err.Error()})
+				continue
+			}
+			log.Info("[Worker] Finish Map task ", task.Map.Id)
+		case rpc.Task_REDUCE:
+			log.Info("[Worker] Start Reduce task ", task.Reduce.Id, " of job ", task.Reduce.JobId)
+			wr.setWorkerState(rpc.WorkerState_BUSY)
+			counters := newTaskCounters()
+			info := reduceTaskInfo(task.Reduce, task.Attempt, side)
+			info.outputs = newNamedOutputs(task.Reduce.OutputDir)
+			output, records, err := wr.runReduce(task.Reduce, funcs, counters, info)
+			wr.setWorkerState(rpc.WorkerState_IDLE)
+			var fetchErr *fetchError
+			if errors.As(err, &fetchErr) {
+				// The master re-runs the lost map task and hands this
+				// reduce task out again with the new location.
+				log.Warn("[Worker] Abort Reduce task ", task.Reduce.Id, ": ", err)
+				wr.Client.ReportFetchFailure(&rpc.FetchFailure{
+					Uuid:     wr.UUID,
+					TaskUuid: task.Uuid,
+					Ip:       fetchErr.IP,
+					Filename: fetchErr.Filename,
+				})
+				continue
+			}
+			if err != nil {
+				log.Warn("[Worker] Reduce task ", task.Reduce.Id, " failed: ", err)
+				wr.Client.ReportTask(&rpc.TaskResult{Uuid: wr.UUID, TaskUuid: task.Uuid, Result: false, Error: err.Error()})
+				continue
+			}
+			if wr.Client.ReportTask(&rpc.TaskResult{
+				Uuid:     wr.UUID,
+				TaskUuid: task.Uuid,
+				Result:   true,
+				Records:  records,
+				Counters: counters.toRPC(),
+			}) {
+				err := commitOutput(output, task.Reduce.OutputDir, task.Reduce.Id)
+				if err == nil {
+					err = info.outputs.commit(fmt.Sprintf("r-%v", task.Reduce.Id))
+				}
+				if err != nil {
+					// The master runs the task again.
+					log.Warn("[Worker] Commit Reduce task ", task.Reduce.Id, " failed: ", err)
+					discardFiles(output)
+					info.outputs.discard()
+					wr.Client.ReportTask(&rpc.TaskResult{Uuid: wr.UUID, TaskUuid: task.Uuid, Result: false, Error: err.Error()})
+					continue
+				}
+			} else {
+				discardFiles(output)
+				info.outputs.discard()
+			}
+			log.Info("[Worker] Finish Reduce task ", task.Reduce.Id)
+		case rpc.Task_EXIT:
+			log.Info("[Worker] Jobs finished, stop pulling tasks")
+			return nil
+		default:
+			time.Sleep(pollInterval)
+		}
+	}
 }
 
-func generateIMDKV(file string) string {
-	b, err := os.ReadFile(file)
+// imdChunkSize is the size of the chunks FetchIMD sends.
+const imdChunkSize = 1 << 20
+
+// GetIMDData returns a whole intermediate file as "key\tvalue\n" lines, for
+// reducers that predate FetchIMD.
+//
+// Deprecated: use FetchIMD, which keeps tabs and newlines in keys and values
+// and is not bound by the gRPC message size.
+func (wr *Worker) GetIMDData(ctx context.Context, in *rpc.IMDLoc) (*rpc.JSONKVs, error) {
+	log.Trace("[Worker] RPC Get intermediate file ", in.Filename)
+	raw, err := os.ReadFile(in.Filename)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	kvs, err := decodeIMDKVs(raw)
 	if err != nil {
-		panic(err)
+		return nil, status.Error(codes.DataLoss, err.Error())
 	}
+	var b strings.Builder
+	for _, kv := range kvs {
+		b.WriteString(kv.Key)
+		b.WriteByte('\t')
+		b.WriteString(kv.Value)
+		b.WriteByte('\n')
+	}
+	return &rpc.JSONKVs{Kvs: b.String()}, nil
+}
 
-	return strings.TrimSpace(string(b))
+// FetchIMD streams an intermediate file from in.Offset on, in chunks, so a
+// partition is never held in one message and an interrupted fetch can resume
+// where it stopped.
+func (wr *Worker) FetchIMD(in *rpc.IMDLoc, stream rpc.Worker_FetchIMDServer) error {
+	log.Trace("[Worker] RPC Fetch intermediate file ", in.Filename)
+	f, err := os.Open(in.Filename)
+	if err != nil {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	defer f.Close()
+	if _, err := f.Seek(in.Offset, io.SeekStart); err != nil {
+		return status.Error(codes.OutOfRange, err.Error())
+	}
+	buf := make([]byte, imdChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&rpc.IMDChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
 }
 
 func (wr *Worker) End(ctx context.Context, in *rpc.Empty) (*rpc.Empty, error) {
@@ -1,6 +1,10 @@
 package worker
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/emptyOVO/mrkit-go/rpc"
+)
 
 func TestReducerForKeyStable(t *testing.T) {
 	nReduce := 8
@@ -24,3 +28,23 @@ func TestReducerForKeyRange(t *testing.T) {
 		}
 	}
 }
+
+func TestRangePartitionKeepsOrder(t *testing.T) {
+	partition := partitioner(&rpc.MapInfo{Partitioner: "range", SplitPoints: []string{"c", "e"}}, nil, 3)
+	for key, want := range map[string]int{"": 0, "a": 0, "c": 1, "d": 1, "e": 2, "zz": 2} {
+		if got, _ := partition(key); got != want {
+			t.Errorf("key %q: expected reducer %d, got %d", key, want, got)
+		}
+	}
+}
+
+func TestPluginPartitionOutOfRange(t *testing.T) {
+	byPrefix := func(key string, nReduce int) int { return int(key[0]-'a') % 4 }
+	partition := partitioner(&rpc.MapInfo{}, byPrefix, 2)
+	if got, err := partition("b-key"); err != nil || got != 1 {
+		t.Fatalf("expected reducer 1, got %d, %v", got, err)
+	}
+	if _, err := partition("c-key"); err == nil {
+		t.Fatal("expected an error for a reducer out of range")
+	}
+}
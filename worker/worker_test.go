@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCallReduceEmitsAnyNumberOfRecords(t *testing.T) {
+	explode := func(key string, values []string, ctx MrContext) {
+		for _, v := range values {
+			if v != "skip" {
+				ctx.Emit(key, v)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	for _, values := range [][]string{{"skip"}, {"a", "skip", "b", "c"}} {
+		if _, err := callReduce(explode, "k", values, &buf, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := buf.String(); got != "k a\nk b\nk c\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+	many := make([]string, 1000)
+	if n, err := callReduce(explode, "k", many, &buf, nil, nil); err != nil || n != 1000 {
+		t.Fatalf("expected 1000 records, got %d, %v", n, err)
+	}
+	if _, err := callReduce(func(string, []string, MrContext) { panic("boom") }, "k", nil, &buf, nil, nil); err == nil {
+		t.Fatal("expected a reduce panic to fail the call")
+	}
+}
+
+func TestDropIMDFilesOfEndedJobs(t *testing.T) {
+	dir := t.TempDir()
+	wr := &Worker{imdFiles: make(map[string][]string)}
+	files := map[string]string{"ended": filepath.Join(dir, "imd-ended"), "running": filepath.Join(dir, "imd-running")}
+	for job, f := range files {
+		if err := os.WriteFile(f, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		wr.keepIMDFiles(job, []string{f})
+	}
+
+	wr.dropIMDFiles([]string{"ended"})
+	if _, err := os.Stat(files["ended"]); !os.IsNotExist(err) {
+		t.Error("the files of an ended job should be deleted")
+	}
+	if _, err := os.Stat(files["running"]); err != nil {
+		t.Error("the files of a running job should be kept")
+	}
+	if jobs := wr.heldJobs(); len(jobs) != 1 || jobs[0] != "running" {
+		t.Errorf("expect to hold files of the running job only, got %v", jobs)
+	}
+}
@@ -7,5 +7,17 @@ func StartWorker(input []string, plugin string, nReducer int, nWorker int, store
 }
 
 func StartWorkerWithAddr(input []string, plugin string, nReducer int, nWorker int, storeInRAM bool, masterAddr string) error {
-	return startWorkerWithMaster(masterAddr, plugin, nWorker, nReducer, storeInRAM)
+	return startWorkerWithMaster(masterAddr, pluginJobFuncs(plugin), nWorker, nReducer, storeInRAM)
+}
+
+// StartAppWorker runs a worker like StartWorker, with the functions of an app
+// registered with worker.Register instead of a plugin.
+func StartAppWorker(input []string, app string, nReducer int, nWorker int, storeInRAM bool) {
+	if err := StartAppWorkerWithAddr(input, app, nReducer, nWorker, storeInRAM, MasterIP); err != nil {
+		panic(err)
+	}
+}
+
+func StartAppWorkerWithAddr(input []string, app string, nReducer int, nWorker int, storeInRAM bool, masterAddr string) error {
+	return startWorkerWithMaster(masterAddr, jobFuncs{app: app}, nWorker, nReducer, storeInRAM)
 }